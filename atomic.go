@@ -0,0 +1,67 @@
+package deep
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// atomicTypes are the non-generic types from sync/atomic. atomic.Pointer[T] is
+// generic, so it is detected by name instead.
+var atomicTypes = map[reflect.Type]struct{}{
+	reflect.TypeFor[atomic.Bool]():    {},
+	reflect.TypeFor[atomic.Int32]():   {},
+	reflect.TypeFor[atomic.Int64]():   {},
+	reflect.TypeFor[atomic.Uint32]():  {},
+	reflect.TypeFor[atomic.Uint64]():  {},
+	reflect.TypeFor[atomic.Uintptr](): {},
+	reflect.TypeFor[atomic.Value]():   {},
+}
+
+// isAtomicType reports whether t is one of the types from sync/atomic with
+// Load and Store methods.
+func isAtomicType(t reflect.Type) bool {
+	if _, ok := atomicTypes[t]; ok {
+		return true
+	}
+
+	return t.PkgPath() == "sync/atomic" && strings.HasPrefix(t.Name(), "Pointer[")
+}
+
+// recursiveCopyAtomic copies the atomic value v into dst by loading its
+// current value and storing it into dst. The values held by atomic.Value and
+// atomic.Pointer[T] are deep copied.
+func recursiveCopyAtomic(v, dst reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	// Load and Store have pointer receivers.
+	loaded := addressable(v).Addr().MethodByName("Load").Call(nil)[0]
+	store := dst.Addr().MethodByName("Store")
+
+	switch loaded.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if loaded.IsNil() {
+			// Nothing was stored, so dst is left with its zero value.
+			return dst, nil
+		}
+
+		if loaded.Kind() == reflect.Interface {
+			loaded = loaded.Elem()
+		}
+
+		copied, err := recursiveCopy(loaded, pointers, cfg)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		// Custom copy logic can return values Store would panic on (e.g.
+		// nil), so those are reported instead.
+		if !copied.IsValid() || !copied.Type().AssignableTo(store.Type().In(0)) {
+			return reflect.Value{}, cfg.incompatible(loaded.Type(), copied)
+		}
+		loaded = copied
+	}
+
+	store.Call([]reflect.Value{loaded})
+
+	return dst, nil
+}
@@ -0,0 +1,126 @@
+package deep
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCopy_Atomic(t *testing.T) {
+	type Payload struct {
+		Value int
+	}
+
+	type S struct {
+		I64     atomic.Int64
+		I32     atomic.Int32
+		U64     atomic.Uint64
+		U32     atomic.Uint32
+		Uintptr atomic.Uintptr
+		Bool    atomic.Bool
+		Ptr     atomic.Pointer[Payload]
+		NilPtr  atomic.Pointer[Payload]
+		Value   atomic.Value
+		Empty   atomic.Value
+	}
+
+	src := &S{}
+	src.I64.Store(42)
+	src.I32.Store(-42)
+	src.U64.Store(43)
+	src.U32.Store(44)
+	src.Uintptr.Store(45)
+	src.Bool.Store(true)
+	src.Ptr.Store(&Payload{Value: 46})
+	src.Value.Store(&Payload{Value: 47})
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if got := dst.I64.Load(); got != 42 {
+		t.Errorf("Expected I64 to be 42, got %d", got)
+	}
+	if got := dst.I32.Load(); got != -42 {
+		t.Errorf("Expected I32 to be -42, got %d", got)
+	}
+	if got := dst.U64.Load(); got != 43 {
+		t.Errorf("Expected U64 to be 43, got %d", got)
+	}
+	if got := dst.U32.Load(); got != 44 {
+		t.Errorf("Expected U32 to be 44, got %d", got)
+	}
+	if got := dst.Uintptr.Load(); got != 45 {
+		t.Errorf("Expected Uintptr to be 45, got %d", got)
+	}
+	if !dst.Bool.Load() {
+		t.Errorf("Expected Bool to be true")
+	}
+
+	if p := dst.Ptr.Load(); p == nil || p.Value != 46 || p == src.Ptr.Load() {
+		t.Errorf("Expected Ptr to hold an independent copy of the payload")
+	}
+
+	if dst.NilPtr.Load() != nil {
+		t.Errorf("Expected NilPtr to be nil")
+	}
+
+	p, ok := dst.Value.Load().(*Payload)
+	if !ok || p.Value != 47 || p == src.Value.Load().(*Payload) {
+		t.Errorf("Expected Value to hold an independent copy of the payload")
+	}
+
+	if dst.Empty.Load() != nil {
+		t.Errorf("Expected Empty to hold nothing")
+	}
+
+	// The copy is independent from the source.
+	src.I64.Add(1)
+	if got := dst.I64.Load(); got != 42 {
+		t.Errorf("Expected copied I64 to stay 42, got %d", got)
+	}
+}
+
+func TestCopy_Atomic_NonAddressable(t *testing.T) {
+	type S struct {
+		Counter atomic.Int64
+	}
+
+	src := &S{}
+	src.Counter.Store(42)
+
+	// Going through an interface makes the struct value non-addressable.
+	v := reflect.ValueOf(reflect.ValueOf(src).Elem().Interface())
+
+	dst, err := CopyValue(v)
+	if err != nil {
+		t.Fatalf("CopyValue failed: %v", err)
+	}
+
+	counter := dst.Field(0).Addr().Interface().(*atomic.Int64)
+	if got := counter.Load(); got != 42 {
+		t.Errorf("Expected Counter to be 42, got %d", got)
+	}
+}
+
+func TestCopy_Atomic_IncompatibleCopy(t *testing.T) {
+	type S struct {
+		Value atomic.Value
+	}
+
+	src := &S{}
+	src.Value.Store(NilCopier{})
+
+	_, err := Copy(src)
+
+	var incompatible *IncompatibleValueError
+	if !errors.As(err, &incompatible) {
+		t.Fatalf("Expected IncompatibleValueError, got %v", err)
+	}
+	if incompatible.Path != "Value" ||
+		incompatible.Type != reflect.TypeFor[NilCopier]() {
+		t.Errorf("Unexpected error contents: %+v", incompatible)
+	}
+}
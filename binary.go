@@ -0,0 +1,61 @@
+package deep
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+)
+
+var binaryMarshalerType = reflect.TypeFor[encoding.BinaryMarshaler]()
+
+// errNoBinaryMarshaling is the error for types given to WithBinaryRoundTrip
+// that can not be marshaled and unmarshaled.
+var errNoBinaryMarshaling = errors.New(
+	"encoding.BinaryMarshaler and encoding.BinaryUnmarshaler are not implemented")
+
+// binaryRoundTrip copies v by marshaling it with MarshalBinary and
+// unmarshaling the result with UnmarshalBinary into a new value. For pointer
+// types, the new value is the target of a new pointer. MarshalBinary can have
+// a pointer receiver, in which case it is called on the address of v, or on
+// that of a shallow copy of v if it is not addressable.
+func binaryRoundTrip(v reflect.Value, cfg *config) (reflect.Value, error) {
+	isPtr := v.Kind() == reflect.Ptr
+	if isPtr && v.IsNil() {
+		return v, nil
+	}
+
+	recv, t := v, v.Type()
+	if isPtr {
+		t = t.Elem()
+	} else if !t.Implements(binaryMarshalerType) {
+		if v.CanAddr() {
+			recv = v.Addr()
+		} else {
+			recv = reflect.New(t)
+			recv.Elem().Set(v)
+		}
+	}
+
+	dst := reflect.New(t)
+	marshaler, ok := recv.Interface().(encoding.BinaryMarshaler)
+	unmarshaler, ok2 := dst.Interface().(encoding.BinaryUnmarshaler)
+	if !ok || !ok2 {
+		return cfg.handleError(v, &BinaryRoundTripError{Type: v.Type(),
+			Err: errNoBinaryMarshaling})
+	}
+
+	data, err := marshaler.MarshalBinary()
+	if err == nil {
+		err = unmarshaler.UnmarshalBinary(data)
+	}
+	if err != nil {
+		return cfg.handleError(v, &BinaryRoundTripError{Type: v.Type(), Err: err})
+	}
+
+	cfg.countAllocation()
+	if isPtr {
+		return dst.Convert(v.Type()), nil
+	}
+
+	return dst.Elem(), nil
+}
@@ -0,0 +1,148 @@
+package deep
+
+import (
+	"container/list"
+	"container/ring"
+	"reflect"
+)
+
+var (
+	listType        = reflect.TypeFor[list.List]()
+	listElementType = reflect.TypeFor[*list.Element]()
+	ringType        = reflect.TypeFor[ring.Ring]()
+)
+
+// recursiveCopyList copies the *list.List v by pushing deep copies of its
+// element values into a new list. The lists link their elements to each other
+// and to the list itself by address, so they have to be rebuilt instead of
+// copied field by field. Pointers to the elements of v found later point to the
+// matching elements of the new list.
+func recursiveCopyList(v reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	src := (*list.List)(v.UnsafePointer())
+
+	var dstList *list.List
+	dst := reflect.Zero(v.Type())
+	if !cfg.dryRun {
+		dstList = list.New()
+		dst = reflect.ValueOf(dstList)
+		cfg.countAllocation()
+	}
+
+	pointers[pointersMapKey{ptr: v.Pointer(), typ: v.Type()}] = dst
+	cfg.countSize(int64(listType.Size()) +
+		int64(src.Len())*int64(listElementType.Elem().Size()))
+
+	i := 0
+	for e := src.Front(); e != nil; e = e.Next() {
+		value, err := recursiveCopyAny(e.Value, i, pointers, cfg)
+		if err != nil {
+			return cfg.failed(dst, err)
+		}
+
+		if !cfg.dryRun {
+			elemDst := dstList.PushBack(value)
+			pointers[pointersMapKey{ptr: reflect.ValueOf(e).Pointer(),
+				typ: listElementType}] = reflect.ValueOf(elemDst)
+		}
+
+		i++
+	}
+
+	return dst, nil
+}
+
+// recursiveCopyRing copies the *ring.Ring v by building a new ring of the same
+// length holding deep copies of the values of v, in the same order. The new
+// ring is returned at the position matching v, and pointers to any other
+// element of v point to the matching element of the new ring.
+func recursiveCopyRing(v reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	src := (*ring.Ring)(v.UnsafePointer())
+
+	// Rings are lazily initialized, and Next would initialize (and so
+	// modify) one that is not linked yet. Those hold a single element.
+	srcElems := []*ring.Ring{src}
+	if isLinkedRing(v.Elem()) {
+		for r := src.Next(); r != src; r = r.Next() {
+			srcElems = append(srcElems, r)
+		}
+	}
+
+	cfg.countSize(int64(len(srcElems)) * int64(ringType.Size()))
+	dstElems := make([]reflect.Value, len(srcElems))
+	for i := range dstElems {
+		dstElems[i] = reflect.Zero(v.Type())
+	}
+	if !cfg.dryRun {
+		r := ring.New(len(srcElems))
+		for i := range dstElems {
+			dstElems[i] = reflect.ValueOf(r)
+			r = r.Next()
+		}
+		cfg.countAllocation()
+	}
+
+	// All elements are recorded before copying any value, so values
+	// referencing the ring point to the new ring.
+	for i, r := range srcElems {
+		pointers[pointersMapKey{ptr: reflect.ValueOf(r).Pointer(),
+			typ: v.Type()}] = dstElems[i]
+	}
+
+	for i, r := range srcElems {
+		value, err := recursiveCopyAny(r.Value, i, pointers, cfg)
+		if err != nil {
+			return cfg.failed(dstElems[0], err)
+		}
+
+		if !cfg.dryRun {
+			dstElems[i].Interface().(*ring.Ring).Value = value
+		}
+	}
+
+	return dstElems[0], nil
+}
+
+// recursiveCopyAny deep copies the value at index i of a list or ring, which is
+// held in an interface, so nil is copied as it is.
+func recursiveCopyAny(value any, i int, pointers pointersMap,
+	cfg *config) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	cfg.countBoxedSize(reflect.TypeOf(value))
+	cfg.pushIndex(i)
+	dst, err := recursiveCopy(reflect.ValueOf(value), pointers, cfg)
+	cfg.popPath()
+	if err != nil {
+		return nil, inIndex(err, i)
+	}
+	if !dst.IsValid() {
+		return nil, nil
+	}
+
+	return dst.Interface(), nil
+}
+
+// isLinkedContainer reports whether the list.List or ring.Ring value v links to
+// other elements by address, which a copy of the value itself could not
+// preserve. Only pointers to them can be copied.
+func isLinkedContainer(v reflect.Value) bool {
+	switch v.Type() {
+	case listType:
+		return !v.IsZero()
+	case ringType:
+		return isLinkedRing(v)
+	}
+
+	return false
+}
+
+// isLinkedRing reports whether the ring.Ring value v was initialized, which
+// links it to itself at least. This is checked without calling any of its
+// methods, as they would initialize it.
+func isLinkedRing(v reflect.Value) bool {
+	return !v.FieldByName("next").IsNil()
+}
@@ -0,0 +1,204 @@
+package deep
+
+import (
+	"container/list"
+	"container/ring"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCopy_List(t *testing.T) {
+	type Value struct {
+		N int
+	}
+
+	type S struct {
+		L    *list.List
+		Last *list.Element
+	}
+
+	src := S{L: list.New()}
+	src.L.PushBack(&Value{N: 1})
+	src.L.PushBack(2)
+	src.L.PushBack(nil)
+	src.Last = src.L.PushBack("four")
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.L == src.L || dst.L.Len() != 4 {
+		t.Fatalf("Expected a new list with 4 elements, got %d", dst.L.Len())
+	}
+
+	e := dst.L.Front()
+	if value := e.Value.(*Value); value.N != 1 || value == src.L.Front().Value {
+		t.Errorf("Expected an independent copy of the first value")
+	}
+	if e = e.Next(); e.Value != 2 {
+		t.Errorf("Expected 2 as the second value, got %v", e.Value)
+	}
+	if e = e.Next(); e.Value != nil {
+		t.Errorf("Expected nil as the third value, got %v", e.Value)
+	}
+	if e = e.Next(); e.Value != "four" {
+		t.Errorf("Expected four as the fourth value, got %v", e.Value)
+	}
+
+	// Pointers to elements point into the copied list, so they can be used
+	// with it.
+	if dst.Last != dst.L.Back() {
+		t.Fatalf("Expected pointer to the last element of the copied list")
+	}
+	dst.L.Remove(dst.Last)
+	if dst.L.Len() != 3 || src.L.Len() != 4 {
+		t.Errorf("Expected copy to be independent from source")
+	}
+
+	src.L.PushFront(0)
+	if dst.L.Len() != 3 {
+		t.Errorf("Expected copy to be independent from source")
+	}
+}
+
+func TestCopy_List_Empty(t *testing.T) {
+	dst, err := Copy(list.New())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Len() != 0 || dst.Front() != nil {
+		t.Errorf("Expected an empty list")
+	}
+
+	dst.PushBack(1)
+	if dst.Len() != 1 {
+		t.Errorf("Expected copy to be usable")
+	}
+}
+
+func TestCopy_List_Error(t *testing.T) {
+	src := list.New()
+	src.PushBack(1)
+	src.PushBack(func() {})
+
+	_, err := Copy(src)
+
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) || unsupported.Path != "[1]" {
+		t.Errorf("Expected UnsupportedTypeError at [1], got %v", err)
+	}
+
+	if err := CanCopy(src); !errors.As(err, &unsupported) {
+		t.Errorf("Expected CanCopy to fail with UnsupportedTypeError, got %v",
+			err)
+	}
+}
+
+func TestCopy_List_Value(t *testing.T) {
+	type S struct {
+		L list.List
+	}
+
+	// Zero lists are empty and usable, so they can be copied.
+	if _, err := Copy(S{}); err != nil {
+		t.Errorf("Copy failed: %v", err)
+	}
+
+	var src S
+	src.L.PushBack(1)
+
+	_, err := Copy(src)
+
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) {
+		t.Errorf("Expected UnsupportedTypeError, got %v", err)
+	}
+
+	dst, err := Copy(src, WithSkipUnsupported())
+	if err != nil {
+		t.Fatalf("Copy with WithSkipUnsupported failed: %v", err)
+	}
+	if dst.L.Len() != 0 {
+		t.Errorf("Expected skipped list to be empty")
+	}
+}
+
+func TestCopy_Ring(t *testing.T) {
+	type S struct {
+		R      *ring.Ring
+		Second *ring.Ring
+	}
+
+	r := ring.New(3)
+	for i := 0; i < 3; i++ {
+		r.Value = []int{i}
+		r = r.Next()
+	}
+	src := S{R: r, Second: r.Next()}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.R == src.R || dst.R.Len() != 3 {
+		t.Fatalf("Expected a new ring with 3 elements, got %d", dst.R.Len())
+	}
+
+	i := 0
+	dst.R.Do(func(value any) {
+		if got := value.([]int); len(got) != 1 || got[0] != i {
+			t.Errorf("Expected [%d] at position %d, got %v", i, i, got)
+		}
+		i++
+	})
+
+	if dst.Second != dst.R.Next() {
+		t.Errorf("Expected pointer to the second element of the copied ring")
+	}
+
+	dst.R.Value.([]int)[0] = 100
+	if src.R.Value.([]int)[0] != 0 {
+		t.Errorf("Expected copy to be independent from source")
+	}
+
+	dst.R.Unlink(1)
+	if dst.R.Len() != 2 || src.R.Len() != 3 {
+		t.Errorf("Expected copy to be independent from source")
+	}
+}
+
+func TestCopy_Ring_SelfReference(t *testing.T) {
+	src := ring.New(2)
+	src.Value = src.Next()
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Value != dst.Next() {
+		t.Errorf("Expected value to point to the next element of the copy")
+	}
+}
+
+func TestCopy_Ring_Unlinked(t *testing.T) {
+	src := &ring.Ring{Value: 1}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst == src || dst.Len() != 1 || dst.Value != 1 {
+		t.Errorf("Expected a new ring with a single element holding 1")
+	}
+
+	// Copying must not initialize the source.
+	if isLinkedRing(reflect.ValueOf(src).Elem()) {
+		t.Errorf("Expected source ring to be left unlinked")
+	}
+}
@@ -0,0 +1,230 @@
+package deep
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CopyAs creates a deep copy of src and converts it to Dst. It returns the
+// converted copy and a nil error in case of success and the zero value for Dst
+// and a non-nil error on failure, including when the copy can not be converted
+// to Dst. Besides the conversions supported by Go, slices, arrays and maps are
+// converted element by element, so e.g. a []MyInt can be copied as a []int,
+// pointers are converted along with the values they point to, and structs are
+// converted field by field. Exported fields are matched by
+// name, which can be changed with a `deep:"rename=Name"` tag on the source
+// field at any level, and fields without a match are left out. The behavior of
+// the copy can be adjusted with the given options.
+func CopyAs[Dst, Src any](src Src, opts ...Option) (Dst, error) {
+	var zero Dst
+
+	copied, err := CopyValue(reflect.ValueOf(src), opts...)
+	if err != nil {
+		return zero, err
+	}
+
+	if !copied.IsValid() {
+		return zero, nil
+	}
+
+	converted, err := convertValue(copied, reflect.TypeFor[Dst](),
+		make(pointersMap))
+	if err != nil {
+		return zero, err
+	}
+
+	return converted.Interface().(Dst), nil
+}
+
+// convertValue converts v to the type t. Converted pointers are recorded in
+// converted by their target type, so cycles terminate and pointer identity is
+// kept.
+func convertValue(v reflect.Value, t reflect.Type,
+	converted pointersMap) (reflect.Value, error) {
+	// Go allows converting integers to strings, but the result is a rune and
+	// not the decimal representation, which is never what a copy wants.
+	if isIntegerKind(v.Kind()) && t.Kind() == reflect.String {
+		return reflect.Value{}, fmt.Errorf("can not convert type %s to type: %s", v.Type(), t)
+	}
+
+	// Conversions between struct types ignore the rename tags, also of
+	// nested structs, so they are only used for types without any.
+	if v.Type().ConvertibleTo(t) && !hasRenamedFields(v.Type()) {
+		return v.Convert(t), nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Slice && t.Kind() == reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(t), nil
+		}
+
+		dst := reflect.MakeSlice(t, v.Len(), v.Cap())
+		if err := convertElems(dst, v, t.Elem(), converted); err != nil {
+			return reflect.Value{}, err
+		}
+
+		return dst, nil
+	case v.Kind() == reflect.Array && t.Kind() == reflect.Array &&
+		v.Len() == t.Len():
+		dst := reflect.New(t).Elem()
+		if err := convertElems(dst, v, t.Elem(), converted); err != nil {
+			return reflect.Value{}, err
+		}
+
+		return dst, nil
+	case v.Kind() == reflect.Map && t.Kind() == reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(t), nil
+		}
+
+		dst := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := convertValue(iter.Key(), t.Key(), converted)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			elem, err := convertValue(iter.Value(), t.Elem(), converted)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			dst.SetMapIndex(key, elem)
+		}
+
+		return dst, nil
+	case v.Kind() == reflect.Ptr && t.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(t), nil
+		}
+
+		key := pointersMapKey{ptr: v.Pointer(), typ: t}
+		if dst, ok := converted[key]; ok {
+			return dst, nil
+		}
+
+		dst := reflect.New(t.Elem())
+		converted[key] = dst
+
+		elem, err := convertValue(v.Elem(), t.Elem(), converted)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		dst.Elem().Set(elem)
+
+		return dst, nil
+	case v.Kind() == reflect.Struct && t.Kind() == reflect.Struct:
+		return convertStruct(v, t, converted)
+	}
+
+	return reflect.Value{}, fmt.Errorf("can not convert type %s to type: %s", v.Type(), t)
+}
+
+// convertStruct converts the struct v to the struct type t by converting the
+// exported fields of v to the fields of t with the same name, or with the name
+// given by their rename tags.
+func convertStruct(v reflect.Value, t reflect.Type,
+	converted pointersMap) (reflect.Value, error) {
+	srcFields := make(map[string]int)
+	for _, field := range structFields(v.Type()) {
+		if !field.exported {
+			continue
+		}
+
+		name := field.name
+		if field.rename != "" {
+			name = field.rename
+		}
+		srcFields[name] = field.index
+	}
+
+	dst := reflect.New(t).Elem()
+	for _, field := range structFields(t) {
+		index, ok := srcFields[field.name]
+		if !ok || !field.exported {
+			continue
+		}
+
+		elem, err := convertValue(v.Field(index), t.Field(field.index).Type,
+			converted)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("field %s: %w", field.name, err)
+		}
+
+		dst.Field(field.index).Set(elem)
+	}
+
+	return dst, nil
+}
+
+// renamedTypes caches what hasRenamedFields reports by type, as it is checked
+// for every converted value.
+var renamedTypes sync.Map
+
+// hasRenamedFields reports whether t has struct fields with a rename tag at any
+// level, including through its elements.
+func hasRenamedFields(t reflect.Type) bool {
+	if renamed, ok := renamedTypes.Load(t); ok {
+		return renamed.(bool)
+	}
+
+	renamed := renamesFields(t, make(map[reflect.Type]struct{}))
+	renamedTypes.Store(t, renamed)
+
+	return renamed
+}
+
+// renamesFields computes hasRenamedFields, skipping the types in visited so
+// recursive types terminate.
+func renamesFields(t reflect.Type, visited map[reflect.Type]struct{}) bool {
+	if _, ok := visited[t]; ok {
+		return false
+	}
+	visited[t] = struct{}{}
+
+	switch t.Kind() {
+	case reflect.Array, reflect.Ptr, reflect.Slice:
+		return renamesFields(t.Elem(), visited)
+	case reflect.Map:
+		return renamesFields(t.Key(), visited) ||
+			renamesFields(t.Elem(), visited)
+	case reflect.Struct:
+		for _, field := range structFields(t) {
+			if field.rename != "" ||
+				renamesFields(t.Field(field.index).Type, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func convertElems(dst, src reflect.Value, elemType reflect.Type,
+	converted pointersMap) error {
+	for i := 0; i < src.Len(); i++ {
+		elem, err := convertValue(src.Index(i), elemType, converted)
+		if err != nil {
+			return err
+		}
+
+		dst.Index(i).Set(elem)
+	}
+
+	return nil
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,267 @@
+package deep
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type convertInt int
+
+type convertInts []convertInt
+
+func TestCopyAs_DefinedToUnderlying(t *testing.T) {
+	dst, err := CopyAs[int](convertInt(42))
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if dst != 42 {
+		t.Errorf("Expected 42, got %d", dst)
+	}
+}
+
+func TestCopyAs_UnderlyingToDefined(t *testing.T) {
+	dst, err := CopyAs[convertInts]([]convertInt{1, 2, 3})
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, convertInts{1, 2, 3}) {
+		t.Errorf("Expected [1 2 3], got %v", dst)
+	}
+}
+
+func TestCopyAs_Slice(t *testing.T) {
+	src := []convertInt{1, 2, 3}
+
+	dst, err := CopyAs[[]int](src)
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, []int{1, 2, 3}) {
+		t.Errorf("Expected [1 2 3], got %v", dst)
+	}
+
+	dst[0] = 100
+	if src[0] != 1 {
+		t.Errorf("Expected copy to be independent from source")
+	}
+}
+
+func TestCopyAs_Slice_Nil(t *testing.T) {
+	var src []convertInt
+
+	dst, err := CopyAs[[]int](src)
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if dst != nil {
+		t.Errorf("Expected nil, got %v", dst)
+	}
+}
+
+func TestCopyAs_Array(t *testing.T) {
+	dst, err := CopyAs[[2]int]([2]convertInt{1, 2})
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if dst != [2]int{1, 2} {
+		t.Errorf("Expected [1 2], got %v", dst)
+	}
+}
+
+func TestCopyAs_Map(t *testing.T) {
+	src := map[convertInt][]convertInt{1: {2, 3}}
+
+	dst, err := CopyAs[map[int][]int](src)
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, map[int][]int{1: {2, 3}}) {
+		t.Errorf("Expected map[1:[2 3]], got %v", dst)
+	}
+}
+
+func TestCopyAs_Struct(t *testing.T) {
+	type Src struct {
+		A int
+		B *string
+	}
+
+	type Dst struct {
+		A int
+		B *string
+	}
+
+	b := "42"
+	src := Src{A: 42, B: &b}
+
+	dst, err := CopyAs[Dst](src)
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if dst.A != 42 || *dst.B != "42" {
+		t.Errorf("Expected {42, 42}, got {%d, %s}", dst.A, *dst.B)
+	}
+
+	if dst.B == src.B {
+		t.Errorf("Expected pointer to be deep copied")
+	}
+}
+
+func TestCopyAs_Struct_Rename(t *testing.T) {
+	type Address struct {
+		Street string `deep:"rename=Line1"`
+	}
+
+	type Src struct {
+		SrcName string `deep:"rename=DstName"`
+		Tags    []string
+		Address *Address
+		Secret  string
+	}
+
+	type AddressDTO struct {
+		Line1 string
+	}
+
+	type Dst struct {
+		DstName string
+		Tags    []string
+		Address *AddressDTO
+		Extra   int
+	}
+
+	src := Src{SrcName: "name", Tags: []string{"a"},
+		Address: &Address{Street: "street"}, Secret: "secret"}
+
+	dst, err := CopyAs[Dst](src)
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if dst.DstName != "name" {
+		t.Errorf("Expected DstName to be name, got %q", dst.DstName)
+	}
+	if dst.Address == nil || dst.Address.Line1 != "street" {
+		t.Errorf("Expected nested renamed field to be converted, got %+v",
+			dst.Address)
+	}
+	if dst.Extra != 0 {
+		t.Errorf("Expected unmatched field to be zero, got %d", dst.Extra)
+	}
+
+	src.Tags[0] = "changed"
+	if dst.Tags[0] != "a" {
+		t.Errorf("Expected copy to be independent from source")
+	}
+
+	// The rename tags do not affect other copies.
+	copied, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if copied.SrcName != "name" {
+		t.Errorf("Expected SrcName to be copied, got %q", copied.SrcName)
+	}
+}
+
+func TestCopyAs_Struct_NestedRename(t *testing.T) {
+	type Src struct {
+		Pair struct {
+			X int `deep:"rename=Y"`
+			Y int `deep:"rename=X"`
+		}
+		Pairs []struct {
+			X int `deep:"rename=Y"`
+			Y int `deep:"rename=X"`
+		}
+	}
+
+	type Dst struct {
+		Pair struct {
+			X int
+			Y int
+		}
+		Pairs []struct {
+			X int
+			Y int
+		}
+	}
+
+	var src Src
+	src.Pair.X, src.Pair.Y = 1, 2
+	src.Pairs = append(src.Pairs, struct {
+		X int `deep:"rename=Y"`
+		Y int `deep:"rename=X"`
+	}{X: 3, Y: 4})
+
+	dst, err := CopyAs[Dst](src)
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if dst.Pair.X != 2 || dst.Pair.Y != 1 {
+		t.Errorf("Expected nested renamed fields to be swapped, got %+v",
+			dst.Pair)
+	}
+	if len(dst.Pairs) != 1 || dst.Pairs[0].X != 4 || dst.Pairs[0].Y != 3 {
+		t.Errorf("Expected renamed fields of element to be swapped, got %+v",
+			dst.Pairs)
+	}
+}
+
+func TestCopyAs_Struct_Cycle(t *testing.T) {
+	type Node struct {
+		Value int `deep:"rename=ID"`
+		Next  *Node
+	}
+
+	type NodeDTO struct {
+		ID   int
+		Next *NodeDTO
+	}
+
+	src := &Node{Value: 1}
+	src.Next = &Node{Value: 2, Next: src}
+
+	dst, err := CopyAs[*NodeDTO](src)
+	if err != nil {
+		t.Fatalf("CopyAs failed: %v", err)
+	}
+
+	if dst.ID != 1 || dst.Next.ID != 2 || dst.Next.Next != dst {
+		t.Errorf("Expected the cycle to be converted, got %+v", dst)
+	}
+}
+
+func TestCopyAs_Struct_Error(t *testing.T) {
+	type Src struct {
+		Value []int
+	}
+
+	type Dst struct {
+		Value []string
+	}
+
+	_, err := CopyAs[Dst](Src{Value: []int{1}})
+	if err == nil || !strings.Contains(err.Error(), "field Value") {
+		t.Errorf("Expected error naming the field, got %v", err)
+	}
+}
+
+func TestCopyAs_NotConvertible(t *testing.T) {
+	if _, err := CopyAs[[]string]([]int{1}); err == nil {
+		t.Errorf("CopyAs did not fail for non-convertible types")
+	}
+
+	if _, err := CopyAs[map[string]int](42); err == nil {
+		t.Errorf("CopyAs did not fail for non-convertible types")
+	}
+}
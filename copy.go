@@ -1,8 +1,16 @@
 package deep
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,24 +21,87 @@ type Copier interface {
 	DeepCopy() interface{}
 }
 
+// TypedCopier is a type-safe alternative to Copier. Types implementing it for
+// their own type (i.e. T is the receiver type) have their DeepCopy() method
+// invoked instead of the default copy logic. As DeepCopy() is usually
+// implemented by copying the receiver with this package, it is not invoked for
+// the root value, which is copied with the default logic instead. Such
+// implementations must not reach their receiver again from its own copy (e.g.
+// through a cycle of pointers), as that would never end.
+type TypedCopier[T any] interface {
+	DeepCopy() T
+}
+
+// CopierErr is like Copier but allows the custom deep copy logic to fail. A
+// non-nil error returned by DeepCopy() is propagated to the caller of Copy.
+type CopierErr interface {
+	DeepCopy() (interface{}, error)
+}
+
+// IntoCopier is an interface for types following the Kubernetes convention of
+// copying themselves into a destination. When a type implements it, its
+// DeepCopyInto() method is called with a pointer to a freshly allocated zero
+// value (*T for a type T, and also *T for a pointer type *T) that it must fill
+// in.
+type IntoCopier interface {
+	DeepCopyInto(dst interface{})
+}
+
 // Copy creates a deep copy of src. It returns the copy and a nil error in case
 // of success and the zero value for the type and a non-nil error on failure.
-func Copy[T any](src T) (T, error) {
-	return copyInternal(src, false)
+// The behavior of the copy can be adjusted with the given options.
+func Copy[T any](src T, opts ...Option) (T, error) {
+	return copyInternal(src, newConfig(opts))
+}
+
+// CopyPtr creates a deep copy of the value pointed to by src and returns a
+// pointer to it, or nil if src is nil. It is the same as Copy for *T, but
+// allows T to be inferred from the pointer. Pointers back to the value pointed
+// to by src, or to anything inside it, point to the copy. The behavior of the
+// copy can be adjusted with the given options.
+func CopyPtr[T any](src *T, opts ...Option) (*T, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	return copyInternal(src, newConfig(opts))
 }
 
 // CopySkipUnsupported creates a deep copy of src. It returns the copy and a nil
 // error in case of success and the zero value for the type and a non-nil error
 // on failure. Unsupported types are skipped (the copy will have the zero value
-// for the type) instead of returning an error.
+// for the type) instead of returning an error. This is done element by element
+// for both arrays and slices, so a [3]chan int is copied as an array of nil
+// channels, just like a []chan int.
 func CopySkipUnsupported[T any](src T) (T, error) {
-	return copyInternal(src, true)
+	return copyInternal(src, newConfig([]Option{WithSkipUnsupported()}))
 }
 
 // MustCopy creates a deep copy of src. It returns the copy on success or panics
-// in case of any failure.
-func MustCopy[T any](src T) T {
-	dst, err := copyInternal(src, false)
+// in case of any failure. The behavior of the copy can be adjusted with the
+// given options.
+func MustCopy[T any](src T, opts ...Option) T {
+	dst, err := copyInternal(src, newConfig(opts))
+	if err != nil {
+		panic(err)
+	}
+
+	return dst
+}
+
+// Clone creates a deep copy of src, mirroring the naming of maps.Clone and
+// slices.Clone. It is the same as MustCopy without options: it returns the copy
+// on success or panics in case of any failure. Use Copy to handle failures as
+// errors instead.
+func Clone[T any](src T) T {
+	return MustCopy(src)
+}
+
+// MustCopySkipUnsupported creates a deep copy of src. It returns the copy on
+// success or panics in case of any failure. Unsupported types are skipped (the
+// copy will have the zero value for the type) instead of causing a panic.
+func MustCopySkipUnsupported[T any](src T) T {
+	dst, err := copyInternal(src, newConfig([]Option{WithSkipUnsupported()}))
 	if err != nil {
 		panic(err)
 	}
@@ -38,25 +109,350 @@ func MustCopy[T any](src T) T {
 	return dst
 }
 
+// CopyContext creates a deep copy of src just like Copy, but aborts the copy
+// and returns the context error if ctx is cancelled before the copy completes.
+func CopyContext[T any](ctx context.Context, src T, opts ...Option) (T, error) {
+	cfg := newConfig(opts)
+	cfg.ctx = ctx
+
+	return copyInternal(src, cfg)
+}
+
+// CopySlice creates a deep copy of the [low:high) range of src. It returns the
+// copy, a new slice with length and capacity high-low, and a nil error in case
+// of success and a nil slice and a non-nil error on failure, including when
+// the range is not valid for src. The behavior of the copy can be adjusted with
+// the given options.
+func CopySlice[T any](src []T, low, high int, opts ...Option) ([]T, error) {
+	if low < 0 || high < low || high > len(src) {
+		return nil, fmt.Errorf("invalid range [%d:%d] for slice of length %d",
+			low, high, len(src))
+	}
+
+	// Limiting the capacity makes sure nothing outside of the range is copied.
+	return copyInternal(src[low:high:high], newConfig(opts))
+}
+
+// CopySliceAll creates a deep copy of s, including all of its elements. It is
+// the same as Copy for []T, spelled out for readability. It returns the copy
+// and a nil error in case of success and a nil slice and a non-nil error on
+// failure. The behavior of the copy can be adjusted with the given options.
+func CopySliceAll[T any](s []T, opts ...Option) ([]T, error) {
+	return copyInternal(s, newConfig(opts))
+}
+
+// CopyMap creates a deep copy of m, including both its keys and its values. It
+// is the same as Copy for map[K]V, spelled out for readability. It returns the
+// copy and a nil error in case of success and a nil map and a non-nil error on
+// failure. The behavior of the copy can be adjusted with the given options.
+func CopyMap[K comparable, V any](m map[K]V, opts ...Option) (map[K]V, error) {
+	return copyInternal(m, newConfig(opts))
+}
+
+// CopyValue creates a deep copy of the value held by v. It returns the copy and
+// a nil error in case of success and an invalid reflect.Value and a non-nil
+// error on failure. If v is invalid, an invalid reflect.Value and a nil error
+// are returned. The behavior of the copy can be adjusted with the given
+// options.
+func CopyValue(v reflect.Value, opts ...Option) (reflect.Value, error) {
+	if !v.IsValid() {
+		return reflect.Value{}, nil
+	}
+
+	// Values obtained through unexported struct fields can not be used to set
+	// other values, so they can not be copied.
+	if !v.CanInterface() {
+		return reflect.Value{}, fmt.Errorf("can not copy value of type %s obtained through unexported field", v.Type())
+	}
+
+	cfg := newConfig(opts)
+	defer cfg.releaseTemporaries()
+	cfg.setRoot(v)
+
+	dst, err := recursiveCopy(v, make(pointersMap), cfg)
+	if err != nil {
+		cfg.writePartial(dst)
+		return reflect.Value{}, err
+	}
+
+	if (cfg.reusePool || v.CanAddr()) && dst.IsValid() {
+		// dst itself might be a pooled temporary, or v itself for plain
+		// values, so it must be moved to memory owned by the caller before
+		// the pool can reuse it, the finalizer modifies it or the caller
+		// modifies v.
+		owned := reflect.New(dst.Type()).Elem()
+		owned.Set(dst)
+		dst = owned
+	}
+
+	return cfg.finalize(dst)
+}
+
+// CopyMany creates deep copies of all the given srcs. It returns the copies, in
+// the same order, and a nil error in case of success and a nil slice and a
+// non-nil error on failure. Pointer identity is preserved across all copies, so
+// if two srcs reference the same object, their copies will also reference the
+// same (copied) object.
+func CopyMany[T any](srcs ...T) ([]T, error) {
+	cfg := newConfig(nil)
+	pointers := make(pointersMap)
+
+	dsts := make([]T, len(srcs))
+	for i, src := range srcs {
+		dst, err := copyWithPointers(src, pointers, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		dsts[i] = dst
+	}
+
+	return dsts, nil
+}
+
+// CopyEach deep copies the elements of src one at a time, passing each copy and
+// its index to fn without retaining the whole copied slice in memory. This
+// allows processing huge slices. Iteration stops at the first error, either
+// from a copy or returned by fn, which is returned. By default, each element
+// is copied independently, so elements referencing the same object get
+// distinct copies of it. WithSharePointersAcrossElements preserves pointer
+// identity across elements instead, like CopyMany does. The behavior of the
+// copy can be adjusted with the given options, except for
+// WithInPlaceSliceReuse, which is ignored.
+func CopyEach[T any](src []T, fn func(i int, copy T) error,
+	opts ...Option) error {
+	cfg := newConfig(opts)
+	// Every element would be copied into the same slice, so the copies
+	// passed to fn would all share it.
+	cfg.reuseSlice = reflect.Value{}
+
+	var pointers pointersMap
+	for i, elem := range src {
+		if pointers == nil || !cfg.sharePointersAcrossElements {
+			pointers = make(pointersMap)
+		}
+
+		cfg.pushIndex(i)
+		dst, err := copyWithPointers(elem, pointers, cfg)
+		cfg.popPath()
+		if err != nil {
+			return inIndex(err, i)
+		}
+
+		if err := fn(i, dst); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CanCopy reports whether src can be deep copied with the given options by
+// walking it exactly like Copy would, without building the copy. It returns
+// the error Copy would return, or nil if the copy would succeed. No pointers,
+// maps, slices or channels are allocated, but custom copy logic (Copier
+// implementations and registered copy functions) still runs.
+// WithPartialOnError is ignored.
+func CanCopy[T any](src T, opts ...Option) error {
+	cfg := newConfig(opts)
+	cfg.dryRun = true
+	cfg.partial = reflect.Value{}
+
+	_, err := copyInternal(src, cfg)
+
+	return err
+}
+
+// CopyInto deep copies src into the value pointed to by dst. It returns a nil
+// error in case of success and a non-nil error on failure, in which case the
+// value pointed to by dst is left untouched. The behavior of the copy can be
+// adjusted with the given options.
+func CopyInto[T any](dst *T, src T, opts ...Option) error {
+	return copyIntoInternal(dst, src, newConfig(opts))
+}
+
+// CopyIntoSkipUnsupported deep copies src into the value pointed to by dst.
+// It returns a nil error in case of success and a non-nil error on failure, in
+// which case the value pointed to by dst is left untouched. Unsupported types
+// are skipped (the copy will have the zero value for the type) instead of
+// returning an error.
+func CopyIntoSkipUnsupported[T any](dst *T, src T) error {
+	return copyIntoInternal(dst, src,
+		newConfig([]Option{WithSkipUnsupported()}))
+}
+
+// contextCheckInterval is how many values are visited between checks for
+// context cancellation.
+const contextCheckInterval = 1024
+
+// visit records that n more values were visited, and returns an error if that
+// exceeds the WithMaxNodes limit or the context of the copy is done.
+func (cfg *config) visit(n int) error {
+	cfg.visited += n
+	cfg.countVisited(n)
+	if cfg.maxNodes > 0 && cfg.visited > cfg.maxNodes {
+		return &MaxNodesError{MaxNodes: cfg.maxNodes}
+	}
+
+	// Checking the context is relatively expensive, so only do it every
+	// contextCheckInterval visited values. Values visited all at once can
+	// skip past a multiple of the interval without landing on it.
+	if cfg.ctx != nil &&
+		cfg.visited/contextCheckInterval != (cfg.visited-n)/contextCheckInterval {
+		return cfg.ctx.Err()
+	}
+
+	return nil
+}
+
+// resetTypes are synchronization primitives whose state (e.g. a held lock) must
+// never be carried over to a copy, so the copy always gets a fresh zero value.
+var resetTypes = map[reflect.Type]struct{}{
+	reflect.TypeFor[sync.Mutex]():     {},
+	reflect.TypeFor[sync.RWMutex]():   {},
+	reflect.TypeFor[sync.Once]():      {},
+	reflect.TypeFor[sync.WaitGroup](): {},
+}
+
+var builderType = reflect.TypeFor[strings.Builder]()
+
+// specialStructTypes are the struct types copied with their own semantics
+// by recursiveCopySpecialStruct, along with resetTypes and the atomic types.
+var specialStructTypes = map[reflect.Type]struct{}{
+	reflect.TypeFor[time.Time]():     {},
+	reflect.TypeFor[big.Int]():       {},
+	reflect.TypeFor[big.Rat]():       {},
+	reflect.TypeFor[big.Float]():     {},
+	reflect.TypeFor[reflect.Value](): {},
+	reflect.TypeFor[bytes.Buffer]():  {},
+	builderType:                      {},
+	reflect.TypeFor[url.Userinfo]():  {},
+	listType:                         {},
+	ringType:                         {},
+	syncMapType:                      {},
+}
+
+// sharedTypes are types whose values are never modified once created or that
+// wrap resources that can not be duplicated, so pointers to them always keep
+// pointing to the same target in the copy. Note
+// the types are the types pointed to, not the pointer types.
+var sharedTypes = map[reflect.Type]struct{}{
+	// The dynamic type behind reflect.Type values.
+	reflect.TypeOf(reflect.TypeFor[int]()).Elem(): {},
+	// Files wrap operating system resources that can not be duplicated.
+	reflect.TypeFor[os.File](): {},
+}
+
+var (
+	errorType  = reflect.TypeFor[error]()
+	closerType = reflect.TypeFor[io.Closer]()
+
+	// copierTypes are the Copier variants that are called through
+	// interfaces.
+	copierTypes = []reflect.Type{reflect.TypeFor[Copier](),
+		reflect.TypeFor[CopierErr](), reflect.TypeFor[IntoCopier]()}
+)
+
+// isStdlibError reports whether t is an error type from the standard library.
+// These keep their messages and wrapped errors in unexported fields, which a
+// copy would lose, and are conventionally immutable, so they are shared.
+func isStdlibError(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Struct:
+	default:
+		return false
+	}
+
+	if !t.Implements(errorType) {
+		return false
+	}
+
+	named := t
+	if named.Kind() == reflect.Ptr {
+		named = named.Elem()
+	}
+
+	return isStdlibPackage(named.PkgPath())
+}
+
+// stdlibRoots are the first elements of the import paths of the standard
+// library packages. Modules can have paths with no dot too, so those alone do
+// not tell standard library packages apart.
+var stdlibRoots = map[string]struct{}{
+	"archive": {}, "bufio": {}, "bytes": {}, "cmp": {}, "compress": {},
+	"container": {}, "context": {}, "crypto": {}, "database": {}, "debug": {},
+	"embed": {}, "encoding": {}, "errors": {}, "expvar": {}, "flag": {},
+	"fmt": {}, "go": {}, "hash": {}, "html": {}, "image": {}, "index": {},
+	"internal": {}, "io": {}, "iter": {}, "log": {}, "maps": {}, "math": {},
+	"mime": {}, "net": {}, "os": {}, "path": {}, "plugin": {}, "reflect": {},
+	"regexp": {}, "runtime": {}, "slices": {}, "sort": {}, "strconv": {},
+	"strings": {}, "structs": {}, "sync": {}, "syscall": {}, "testing": {},
+	"text": {}, "time": {}, "unicode": {}, "unique": {}, "unsafe": {},
+	"uuid": {}, "vendor": {}, "weak": {},
+}
+
+// isStdlibPackage reports whether pkgPath is the import path of a standard
+// library package.
+func isStdlibPackage(pkgPath string) bool {
+	first, _, _ := strings.Cut(pkgPath, "/")
+	_, ok := stdlibRoots[first]
+
+	return ok
+}
+
 type pointersMapKey struct {
 	ptr uintptr
 	typ reflect.Type
+	// len and cap are only set for slices, so that distinct slices sharing
+	// the same backing array are still copied independently. They are -1 for
+	// the backing arrays recorded with WithSharedBackingArrays.
+	len int
+	cap int
 }
 type pointersMap map[pointersMapKey]reflect.Value
 
-func copyInternal[T any](src T, skipUnsupported bool) (T, error) {
-	v := reflect.ValueOf(src)
+func copyInternal[T any](src T, cfg *config) (T, error) {
+	return copyWithPointers(src, nil, cfg)
+}
 
-	// If src is the zero value for its type (e.g. an uninitialized interface,
-	// or if T is 'any' and src is its zero value), v will be invalid.
-	if !v.IsValid() {
+// copyWithPointers copies src using the given pointers map, so multiple copies
+// can share pointer identity. A nil pointers map is only created once src is
+// known to need one.
+func copyWithPointers[T any](src T, pointers pointersMap,
+	cfg *config) (T, error) {
+	// Trivially copyable values are copied by the assignment already, so
+	// they are returned without boxing them in a reflect.Value.
+	if t := reflect.TypeFor[T](); t.Kind() != reflect.Interface &&
+		cfg.finalizer == nil && cfg.canShareTrivial() && isTriviallyCopyable(t) {
+		return src, nil
+	}
+
+	defer cfg.releaseTemporaries()
+
+	v := rootValue(src)
+
+	// If src is a nil interface (e.g. if T is 'any' and src is its zero
+	// value), there is nothing to copy.
+	if v.Kind() == reflect.Interface && v.IsNil() {
 		// This amounts to returning the zero value for T.
 		var t T
 		return t, nil
 	}
 
-	dst, err := recursiveCopy(v, make(pointersMap),
-		skipUnsupported)
+	cfg.setRoot(v)
+
+	if pointers == nil {
+		pointers = make(pointersMap)
+	}
+
+	dst, err := recursiveCopy(v, pointers, cfg)
+	if err != nil {
+		cfg.writePartial(dst)
+		var t T
+		return t, err
+	}
+
+	dst, err = cfg.finalize(dst)
 	if err != nil {
 		var t T
 		return t, err
@@ -71,7 +467,9 @@ func copyInternal[T any](src T, skipUnsupported bool) (T, error) {
 
 	switch dst.Kind() {
 	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
-		if dst.IsNil() || dst.IsZero() {
+		// For these kinds IsZero is the same as IsNil, so empty but non-nil
+		// maps and slices are returned as they are.
+		if dst.IsNil() {
 			// The zero value for these types is nil, so this is the correct
 			// and type-safe way to return nil.
 			var zero T
@@ -84,190 +482,1230 @@ func copyInternal[T any](src T, skipUnsupported bool) (T, error) {
 	return dst.Interface().(T), nil
 }
 
+// rootValue returns the reflect.Value for src with T as its type. For
+// interface types, reflect.ValueOf would return a value of the dynamic type
+// instead, so options and copy functions given for T would not apply to it.
+func rootValue[T any](src T) reflect.Value {
+	if reflect.TypeFor[T]().Kind() == reflect.Interface {
+		return reflect.ValueOf(&src).Elem()
+	}
+
+	return reflect.ValueOf(src)
+}
+
+// setRoot records the depth the root value v is copied at.
+func (cfg *config) setRoot(v reflect.Value) {
+	cfg.rootDepth = 0
+	if v.Kind() == reflect.Interface {
+		cfg.rootDepth = 1
+	}
+}
+
+// atRoot reports whether the value being copied is the root value, or the
+// dynamic value of a root interface.
+func (cfg *config) atRoot() bool {
+	return cfg.depth == cfg.rootDepth
+}
+
+// inRoot is atRoot for the copy functions of containers, which run once the
+// container counts as one level of nesting.
+func (cfg *config) inRoot() bool {
+	return cfg.depth == cfg.rootDepth+1
+}
+
+func copyIntoInternal[T any](dst *T, src T, cfg *config) error {
+	if dst == nil {
+		return fmt.Errorf("%w for type: %s", ErrNilDestination,
+			reflect.TypeOf(dst).Elem())
+	}
+
+	defer cfg.releaseTemporaries()
+
+	v := rootValue(src)
+
+	// If src is a nil interface, the destination just gets the zero value for
+	// T.
+	if v.Kind() == reflect.Interface && v.IsNil() {
+		var zero T
+		*dst = zero
+		return nil
+	}
+
+	cfg.setRoot(v)
+
+	copied, err := recursiveCopy(v, make(pointersMap), cfg)
+	if err != nil {
+		cfg.writePartial(copied)
+		return err
+	}
+
+	copied, err = cfg.finalize(copied)
+	if err != nil {
+		return err
+	}
+
+	if !copied.IsValid() {
+		var zero T
+		*dst = zero
+		return nil
+	}
+
+	reflect.ValueOf(dst).Elem().Set(copied)
+
+	return nil
+}
+
 func recursiveCopy(v reflect.Value, pointers pointersMap,
-	skipUnsupported bool) (reflect.Value, error) {
+	cfg *config) (reflect.Value, error) {
+	return recursiveCopyWithInfo(v, nil, pointers, cfg)
+}
+
+// recursiveCopyWithInfo is recursiveCopy for when the typeInfo for the type of
+// v is already known, as it is for the fields and elements of containers. A nil
+// info is looked up.
+func recursiveCopyWithInfo(v reflect.Value, info *typeInfo,
+	pointers pointersMap, cfg *config) (reflect.Value, error) {
+	if err := cfg.visit(1); err != nil {
+		return reflect.Value{}, err
+	}
+
+	if cfg.strictResources && isResource(v) {
+		return cfg.handleError(v, &ResourceError{Type: v.Type()})
+	}
+
+	if cfg.transform != nil {
+		if dst, ok := cfg.transform(cfg.currentPath(), v); ok {
+			return checkTransformResult(v, dst)
+		}
+	}
+
+	if info == nil {
+		info = infoFor(v.Type())
+	}
+	if info.shallow || info.stdlibError || info.sharedPointer {
+		return v, nil
+	}
+	if len(cfg.shallowTypes) > 0 {
+		if _, ok := cfg.shallowTypes[v.Type()]; ok {
+			return v, nil
+		}
+	}
+
+	if len(cfg.binaryRoundTripTypes) > 0 {
+		if _, ok := cfg.binaryRoundTripTypes[v.Type()]; ok {
+			return binaryRoundTrip(v, cfg)
+		}
+	}
+
+	// Plain values are copied when they are assigned, so they can be used as
+	// their own copy.
+	switch v.Kind() {
+	case reflect.Array, reflect.Struct:
+		if info.trivial && cfg.canShareTrivial() {
+			return v, nil
+		}
+		if info.immutable && cfg.shareImmutable {
+			return v, nil
+		}
+	}
 
 	if v.CanInterface() {
-		if copier, ok := v.Interface().(Copier); ok {
-			return reflect.ValueOf(copier.DeepCopy()), nil
+		if info.hasRegistered {
+			return info.registered.call(v, pointers, cfg)
+		}
+
+		// The dynamic values of interfaces may implement a Copier variant
+		// their type does not.
+		if info.copier || v.Kind() == reflect.Interface {
+			if dst, ok, err := callCopier(v, cfg); ok {
+				return dst, err
+			}
 		}
+
+		if info.typedCopier.IsValid() && !cfg.atRoot() {
+			return callTypedCopier(v, info.typedCopier), nil
+		}
+
+		if info.pointerCopier && !cfg.atRoot() {
+			return callPointerCopier(v, cfg)
+		}
+	}
+
+	// Descending into a non-nil container counts as one level of nesting.
+	switch v.Kind() {
+	case reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		fallthrough
+	case reflect.Array, reflect.Struct:
+		if cfg.maxDepth > 0 && cfg.depth >= cfg.maxDepth {
+			return cfg.unsupported(v, &MaxDepthError{MaxDepth: cfg.maxDepth,
+				Type: v.Type()})
+		}
+
+		cfg.depth++
+		cfg.countDepth()
+		dst, err := recursiveCopyKind(v, info, pointers, cfg)
+		cfg.depth--
+
+		return dst, err
 	}
 
+	return recursiveCopyKind(v, info, pointers, cfg)
+}
+
+// recursiveCopyKind copies v with the copy function for its kind. info is the
+// typeInfo for the type of v.
+func recursiveCopyKind(v reflect.Value, info *typeInfo, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
 	switch v.Kind() {
 	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
 		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
-		reflect.Uint64, reflect.Uintptr, reflect.Float32, reflect.Float64,
-		reflect.Complex64, reflect.Complex128, reflect.String:
+		reflect.Uint64, reflect.Float32, reflect.Float64, reflect.Complex64,
+		reflect.Complex128:
 		// Direct type, just copy it.
 		return v, nil
+	case reflect.String:
+		cfg.countSize(int64(v.Len()))
+		if cfg.internStrings {
+			return cfg.intern(v), nil
+		}
+
+		return v, nil
+	case reflect.Uintptr:
+		if !cfg.errorOnUintptr || v.Uint() == 0 {
+			return v, nil
+		}
+
+		// The value may really be a pointer.
+		return cfg.unsupported(v, &UnsupportedTypeError{Type: v.Type()})
 	case reflect.Array:
-		return recursiveCopyArray(v, pointers, skipUnsupported)
+		return recursiveCopyArray(v, info, pointers, cfg)
 	case reflect.Interface:
-		return recursiveCopyInterface(v, pointers, skipUnsupported)
+		return recursiveCopyInterface(v, pointers, cfg)
 	case reflect.Map:
-		return recursiveCopyMap(v, pointers, skipUnsupported)
+		return recursiveCopyMap(v, info, pointers, cfg)
 	case reflect.Ptr:
-		return recursiveCopyPtr(v, pointers, skipUnsupported)
+		return recursiveCopyPtr(v, info, pointers, cfg)
 	case reflect.Slice:
-		return recursiveCopySlice(v, pointers, skipUnsupported)
+		return recursiveCopySlice(v, info, pointers, cfg)
 	case reflect.Struct:
-		return recursiveCopyStruct(v, pointers, skipUnsupported)
+		return recursiveCopyStruct(v, info, pointers, cfg)
 	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
 		if v.IsNil() {
 			// If we have a nil function, unsafe pointer or channel, then we
 			// can copy it.
 			return v, nil
+		} else if v.Kind() == reflect.Chan && cfg.newChannels {
+			return recursiveCopyChan(v, pointers, cfg)
+		} else if v.Kind() == reflect.Chan && cfg.resetChannels {
+			return reflect.Zero(v.Type()), nil
+		} else if v.Kind() == reflect.Func && cfg.shareFuncs {
+			// Functions are immutable, so they can be shared.
+			return v, nil
 		} else {
-			if skipUnsupported {
-				return reflect.Zero(v.Type()), nil
-			} else {
-				return reflect.Value{}, fmt.Errorf("unsuported non-nil value for type: %s", v.Type())
-			}
+			return cfg.unsupported(v, &UnsupportedTypeError{Type: v.Type()})
 		}
 	default:
-		if skipUnsupported {
+		return cfg.unsupported(v, &UnsupportedTypeError{Type: v.Type()})
+	}
+}
+
+// intern returns a string value equal to v that shares its memory with all
+// the equal strings interned before.
+func (cfg *config) intern(v reflect.Value) reflect.Value {
+	if cfg.interned == nil {
+		cfg.interned = make(map[string]reflect.Value)
+	}
+
+	interned, ok := cfg.interned[v.String()]
+	if !ok {
+		cfg.interned[v.String()] = v
+		return v
+	}
+
+	if interned.Type() != v.Type() {
+		// Same string, but of a different named type.
+		return interned.Convert(v.Type())
+	}
+
+	return interned
+}
+
+// finalize calls the WithFinalize function, if any, with the copy dst of the
+// root value. dst is moved to a settable value first if needed, so the function
+// can modify it, and the value it was called with is returned. Dry runs have
+// no copy to finalize.
+func (cfg *config) finalize(dst reflect.Value) (reflect.Value, error) {
+	if cfg.finalizer == nil || cfg.dryRun || !dst.IsValid() {
+		return dst, nil
+	}
+
+	if !dst.CanSet() {
+		settable := reflect.New(dst.Type()).Elem()
+		settable.Set(dst)
+		dst = settable
+	}
+
+	if err := cfg.finalizer(dst); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return dst, nil
+}
+
+// skip returns the value used in place of v when it is skipped, recording it
+// in the skip report if there is one. That is the WithFieldDefault value for
+// its path, or else the zero value.
+func (cfg *config) skip(v reflect.Value) (reflect.Value, error) {
+	if cfg.skipReport != nil {
+		*cfg.skipReport = append(*cfg.skipReport,
+			SkippedField{Path: cfg.currentPath(), Type: v.Type()})
+	}
+
+	cfg.trace(TraceSkip, v.Type())
+
+	if value, ok := cfg.fieldDefaults[cfg.currentPath()]; ok {
+		if !value.IsValid() {
 			return reflect.Zero(v.Type()), nil
-		} else {
-			return reflect.Value{}, fmt.Errorf("unsuported type: %s", v.Type())
 		}
+		if !value.Type().AssignableTo(v.Type()) {
+			return reflect.Value{}, cfg.incompatible(v.Type(), value)
+		}
+
+		return value, nil
+	}
+
+	return reflect.Zero(v.Type()), nil
+}
+
+// unsupported handles the value v that can not be copied because of err,
+// which is skipped with WithSkipUnsupported unless there is a WithErrorHandler
+// handler to decide.
+func (cfg *config) unsupported(v reflect.Value, err error) (reflect.Value, error) {
+	if cfg.skipUnsupported && cfg.errorHandler == nil {
+		return cfg.skip(v)
+	}
+
+	return cfg.handleError(v, err)
+}
+
+// handleError handles the value v that can not be copied because of err, as
+// decided by the WithErrorHandler handler. Without one, err is returned.
+func (cfg *config) handleError(v reflect.Value, err error) (reflect.Value, error) {
+	if cfg.errorHandler == nil {
+		return reflect.Value{}, err
+	}
+
+	switch cfg.errorHandler(cfg.currentPath(), v.Type(), err) {
+	case Skip:
+		return cfg.skip(v)
+	case Share:
+		return v, nil
+	default:
+		return reflect.Value{}, err
+	}
+}
+
+// set sets dst to the copy v. Custom copy logic can return values that can not
+// be used in place of the source value (e.g. nil), which would make Set panic
+// with an unhelpful message, so this is reported as an error instead.
+func (cfg *config) set(dst, v reflect.Value) error {
+	if !v.IsValid() || !v.Type().AssignableTo(dst.Type()) {
+		return cfg.incompatible(dst.Type(), v)
+	}
+
+	dst.Set(v)
+
+	return nil
+}
+
+// addressable returns v if it is addressable, or else an addressable copy of
+// it. A non-addressable v is already a private copy of the value it was
+// obtained from, so copying it again to get an address is safe.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+
+	addr := reflect.New(v.Type()).Elem()
+	addr.Set(v)
+
+	return addr
+}
+
+// setMapIndex sets the copied key and elem in the map dst, like set does for
+// other values. SetMapIndex deletes the key for invalid elements instead of
+// panicking, so those are reported too.
+func (cfg *config) setMapIndex(dst, key, elem reflect.Value) error {
+	if !key.IsValid() || !key.Type().AssignableTo(dst.Type().Key()) {
+		return cfg.incompatible(dst.Type().Key(), key)
+	}
+	if !elem.IsValid() || !elem.Type().AssignableTo(dst.Type().Elem()) {
+		return cfg.incompatible(dst.Type().Elem(), elem)
+	}
+
+	dst.SetMapIndex(key, elem)
+
+	return nil
+}
+
+// incompatible returns the error for the copy v that can not be used as a
+// value of type t.
+func (cfg *config) incompatible(t reflect.Type, v reflect.Value) error {
+	err := &IncompatibleValueError{Type: t}
+	if v.IsValid() {
+		err.ValueType = v.Type()
+	}
+
+	return err
+}
+
+// callCopier invokes the Copier variant implemented by v, and reports false if
+// there is none.
+func callCopier(v reflect.Value, cfg *config) (reflect.Value, bool, error) {
+	src := v.Interface()
+	switch src.(type) {
+	case CopierErr, Copier, IntoCopier:
+	default:
+		return reflect.Value{}, false, nil
+	}
+
+	switch copier := src.(type) {
+	case CopierErr:
+		copied, err := copier.DeepCopy()
+		if err != nil {
+			handled, err := cfg.handleError(v, err)
+			return handled, true, err
+		}
+		dst, err := cfg.checkCopierResult(v, reflect.ValueOf(copied))
+		return dst, true, err
+	case Copier:
+		dst, err := cfg.checkCopierResult(v, reflect.ValueOf(copier.DeepCopy()))
+		return dst, true, err
+	default:
+		dst, ok := callIntoCopier(v, copier.(IntoCopier))
+		return dst, ok, nil
+	}
+}
+
+// checkCopierResult makes sure the value returned by a custom copier for v can
+// actually be used in place of v.
+func (cfg *config) checkCopierResult(v,
+	dst reflect.Value) (reflect.Value, error) {
+	if dst.IsValid() && !dst.Type().AssignableTo(v.Type()) {
+		return reflect.Value{}, cfg.incompatible(v.Type(), dst)
+	}
+
+	return dst, nil
+}
+
+// checkTransformResult makes sure the value returned by the transform for v
+// can be used in place of v. An invalid value stands for the zero value.
+func checkTransformResult(v, dst reflect.Value) (reflect.Value, error) {
+	if !dst.IsValid() {
+		return reflect.Zero(v.Type()), nil
+	}
+
+	if !dst.Type().AssignableTo(v.Type()) {
+		return reflect.Value{}, &IncompatibleValueError{Type: v.Type(),
+			ValueType: dst.Type()}
+	}
+
+	return dst, nil
+}
+
+// callIntoCopier invokes the DeepCopyInto() method of v with a freshly
+// allocated destination and returns the filled in value. Nil pointers are not
+// handled, so they are copied as usual. For interfaces, the destination is
+// allocated for their dynamic value.
+func callIntoCopier(v reflect.Value, copier IntoCopier) (reflect.Value, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		dst := reflect.New(v.Type().Elem())
+		copier.DeepCopyInto(dst.Interface())
+		return dst, true
+	}
+
+	dst := reflect.New(v.Type())
+	copier.DeepCopyInto(dst.Interface())
+	return dst.Elem(), true
+}
+
+// typedCopierMethod returns the DeepCopy() method of the type t if it
+// implements TypedCopier for its own type.
+func typedCopierMethod(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Interface {
+		return reflect.Value{}
+	}
+
+	m, ok := t.MethodByName("DeepCopy")
+	if !ok || m.Type.NumIn() != 1 || m.Type.NumOut() != 1 ||
+		m.Type.Out(0) != t {
+		return reflect.Value{}
+	}
+
+	return m.Func
+}
+
+// callTypedCopier invokes the DeepCopy() method of v implementing TypedCopier.
+// The root value is left out by the caller, as copying it is what DeepCopy()
+// itself asks for when it copies its receiver with this package.
+func callTypedCopier(v, method reflect.Value) reflect.Value {
+	return method.Call([]reflect.Value{v})[0]
+}
+
+// implementsPointerCopier reports whether the type t has a Copier, CopierErr
+// or IntoCopier implementation with a pointer receiver, so values of t do not
+// implement it but pointers to them do. Only defined types can declare
+// methods. Methods promoted from embedded fields are left out, as they would
+// copy the embedded field only, and those are copied with their own copier
+// anyway.
+func implementsPointerCopier(t reflect.Type) bool {
+	// Predeclared types have no package either.
+	if t.Name() == "" || t.PkgPath() == "" ||
+		t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return false
+	}
+
+	for _, copierType := range copierTypes {
+		if !t.Implements(copierType) &&
+			reflect.PointerTo(t).Implements(copierType) &&
+			!embedsCopier(t, copierType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// embedsCopier reports whether the struct type t has an embedded field that
+// the methods of copierType could be promoted from.
+func embedsCopier(t reflect.Type, copierType reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && (field.Type.Implements(copierType) ||
+			reflect.PointerTo(field.Type).Implements(copierType)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// callPointerCopier invokes the Copier variant implemented with a pointer
+// receiver for v. The receiver is the address of v if it is addressable, or
+// that of a shallow copy of v otherwise (e.g. for map values). Copiers with a
+// pointer receiver usually return a pointer to the copy, which is
+// dereferenced. Like typed copiers,
+// these are not invoked for the root value, as they often copy the value they
+// point to with this package.
+func callPointerCopier(v reflect.Value, cfg *config) (reflect.Value, error) {
+	var recv reflect.Value
+	if v.CanAddr() {
+		recv = v.Addr()
+	} else {
+		recv = reflect.New(v.Type())
+		recv.Elem().Set(v)
+	}
+
+	var dst reflect.Value
+	switch copier := recv.Interface().(type) {
+	case CopierErr:
+		copied, err := copier.DeepCopy()
+		if err != nil {
+			return cfg.handleError(v, err)
+		}
+		dst = reflect.ValueOf(copied)
+	case Copier:
+		dst = reflect.ValueOf(copier.DeepCopy())
+	case IntoCopier:
+		dst, _ = callIntoCopier(v, copier)
+		return dst, nil
+	}
+
+	if dst.IsValid() && dst.Type() == recv.Type() {
+		if dst.IsNil() {
+			return reflect.Value{}, nil
+		}
+		dst = dst.Elem()
 	}
+
+	return cfg.checkCopierResult(v, dst)
 }
 
-func recursiveCopyArray(v reflect.Value, pointers pointersMap,
-	skipUnsupported bool) (reflect.Value, error) {
-	dst := reflect.New(v.Type()).Elem()
+func recursiveCopyArray(v reflect.Value, info *typeInfo,
+	pointers pointersMap, cfg *config) (reflect.Value, error) {
+	dst := newTemporary(v.Type(), cfg)
+
+	// Arrays are values, so arrays of plain values can be copied with a
+	// single assignment. The elements still count as visited.
+	if cfg.canBulkCopy(v.Type().Elem()) {
+		if err := cfg.visit(v.Len()); err != nil {
+			return reflect.Value{}, err
+		}
+
+		dst.Set(v)
+		return dst, nil
+	}
 
+	elemInfo := info.child(0, v.Type().Elem())
 	for i := 0; i < v.Len(); i++ {
 		elem := v.Index(i)
-		elemDst, err := recursiveCopy(elem, pointers, skipUnsupported)
+		cfg.pushIndex(i)
+		elemDst, err := recursiveCopyWithInfo(elem, elemInfo, pointers, cfg)
 		if err != nil {
-			return reflect.Value{}, err
+			cfg.popPath()
+			cfg.setPartial(dst.Index(i), elemDst)
+			return cfg.failed(dst, inIndex(err, i))
+		}
+
+		err = cfg.set(dst.Index(i), elemDst)
+		cfg.popPath()
+		if err != nil {
+			return cfg.failed(dst, inIndex(err, i))
 		}
+	}
+
+	return dst, nil
+}
+
+// recursiveCopyChan returns a new, empty channel with the same type and
+// capacity as v. Buffered values are not copied, as they can not be read
+// without consuming them.
+func recursiveCopyChan(v reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	// The same channel referenced multiple times becomes the same new channel.
+	key := pointersMapKey{ptr: v.Pointer(), typ: v.Type()}
+	if dst, ok := pointers[key]; ok {
+		cfg.countDeduped()
+		cfg.trace(TraceDedupHit, v.Type())
+		return dst, nil
+	}
 
-		dst.Index(i).Set(elemDst)
+	cfg.countSize(int64(v.Cap()) * int64(v.Type().Elem().Size()))
+	if cfg.dryRun {
+		return reflect.Zero(v.Type()), nil
 	}
 
+	// MakeChan only supports bidirectional channels, so directional ones are
+	// created as such and then converted.
+	chanType := reflect.ChanOf(reflect.BothDir, v.Type().Elem())
+	dst := reflect.MakeChan(chanType, v.Cap()).Convert(v.Type())
+	cfg.countAllocation()
+
+	pointers[key] = dst
+
 	return dst, nil
 }
 
 func recursiveCopyInterface(v reflect.Value, pointers pointersMap,
-	skipUnsupported bool) (reflect.Value, error) {
+	cfg *config) (reflect.Value, error) {
 	if v.IsNil() {
 		// If the interface is nil, just return it.
 		return v, nil
 	}
 
-	return recursiveCopy(v.Elem(), pointers, skipUnsupported)
+	if cfg.interfaceFactory != nil {
+		if dst, ok := cfg.interfaceFactory(v.Elem().Type()); ok {
+			if !dst.IsValid() {
+				return reflect.Zero(v.Type()), nil
+			}
+
+			if !dst.Type().AssignableTo(v.Type()) {
+				return reflect.Value{}, cfg.incompatible(v.Type(), dst)
+			}
+
+			return dst, nil
+		}
+	}
+
+	// Values that can be closed usually wrap live resources (connections,
+	// files, etc.), which copying would corrupt.
+	if cfg.shareClosers && v.Elem().Type().Implements(closerType) {
+		return v, nil
+	}
+
+	cfg.countBoxedSize(v.Elem().Type())
+
+	// The dynamic value is not memoized here. A value can only reach the
+	// interface holding it again through a pointer, map or slice, and those
+	// are all memoized, so cycles through interfaces always terminate.
+	return recursiveCopy(v.Elem(), pointers, cfg)
 }
 
-func recursiveCopyMap(v reflect.Value, pointers pointersMap,
-	skipUnsupported bool) (reflect.Value, error) {
+func recursiveCopyMap(v reflect.Value, info *typeInfo,
+	pointers pointersMap, cfg *config) (reflect.Value, error) {
 	if v.IsNil() {
 		// If the slice is nil, just return it.
 		return v, nil
 	}
 
-	dst := reflect.MakeMap(v.Type())
+	// A map can reach itself through an interface value, so it is memoized
+	// the same way pointers are.
+	key := pointersMapKey{ptr: v.Pointer(), typ: v.Type()}
+	if dst, ok := pointers[key]; ok {
+		cfg.countDeduped()
+		cfg.trace(TraceDedupHit, v.Type())
+		return dst, nil
+	}
+
+	cfg.countSize(int64(v.Len()) *
+		int64(v.Type().Key().Size()+v.Type().Elem().Size()))
+	dst := reflect.Zero(v.Type())
+	if !cfg.dryRun {
+		// Sizing the map up front avoids growing it while it is filled.
+		dst = reflect.MakeMapWithSize(v.Type(), v.Len())
+		cfg.countAllocation()
+	}
+
+	pointers[key] = dst
+
+	keys := v.MapKeys()
+	if cfg.sortedMapKeys {
+		sortMapKeys(keys)
+	}
+
+	elemInfo := info.child(0, v.Type().Elem())
+	keyInfo := info.child(1, v.Type().Key())
+	for _, key := range keys {
+		// Keys may hold pointers too (directly, or inside arrays and
+		// structs), so they are deep copied just like values.
+		cfg.pushKey(key)
+
+		keyDst, err := copyMapKey(key, keyInfo, pointers, cfg)
+		if err != nil {
+			cfg.popPath()
+			return cfg.failed(dst, inKey(err, key))
+		}
 
-	for _, key := range v.MapKeys() {
 		elem := v.MapIndex(key)
-		elemDst, err := recursiveCopy(elem, pointers,
-			skipUnsupported)
+		elemDst, err := recursiveCopyWithInfo(elem, elemInfo, pointers, cfg)
 		if err != nil {
-			return reflect.Value{}, err
+			cfg.popPath()
+			if elemDst.IsValid() {
+				dst.SetMapIndex(keyDst, elemDst)
+			}
+			return cfg.failed(dst, inKey(err, key))
 		}
 
-		dst.SetMapIndex(key, elemDst)
+		if !cfg.dryRun {
+			err = cfg.setMapIndex(dst, keyDst, elemDst)
+		}
+		cfg.popPath()
+		if err != nil {
+			return cfg.failed(dst, inKey(err, key))
+		}
 	}
 
 	return dst, nil
 }
 
-func recursiveCopyPtr(v reflect.Value, pointers pointersMap,
-	skipUnsupported bool) (reflect.Value, error) {
+func recursiveCopyPtr(v reflect.Value, info *typeInfo,
+	pointers pointersMap, cfg *config) (reflect.Value, error) {
 	// If the pointer is nil, just return it.
 	if v.IsNil() {
 		return v, nil
 	}
 
+	// Pointers to shared types keep pointing to the same target.
+	if len(cfg.sharedPointerTypes) > 0 {
+		if _, ok := cfg.sharedPointerTypes[v.Type().Elem()]; ok {
+			return v, nil
+		}
+	}
+
 	ptr := v.Pointer()
 	typ := v.Type()
-	key := pointersMapKey{ptr, typ}
+	key := pointersMapKey{ptr: ptr, typ: typ}
 
 	// If the pointer is already in the pointers map, return it.
 	if dst, ok := pointers[key]; ok {
+		cfg.countDeduped()
+		cfg.trace(TraceDedupHit, v.Type())
 		return dst, nil
 	}
 
-	// Otherwise, create a new pointer and add it to the pointers map.
-	dst := reflect.New(v.Type().Elem())
+	cfg.trace(TraceCopyPointer, v.Type())
+
+	switch v.Type().Elem() {
+	case listType:
+		return recursiveCopyList(v, pointers, cfg)
+	case ringType:
+		return recursiveCopyRing(v, pointers, cfg)
+	case builderType:
+		return recursiveCopyBuilder(v, pointers, cfg)
+	}
+
+	// Otherwise, create a new pointer and add it to the pointers map. Dry
+	// runs allocate nothing, but still record the pointer so that cycles
+	// terminate.
+	cfg.countSize(int64(v.Type().Elem().Size()))
+	dst := reflect.Zero(v.Type())
+	if !cfg.dryRun {
+		dst = reflect.New(v.Type().Elem())
+		cfg.countAllocation()
+	}
 
 	pointers[key] = dst
 
 	// Proceed with the copy.
 	elem := v.Elem()
-	elemDst, err := recursiveCopy(elem, pointers, skipUnsupported)
+	elemDst, err := recursiveCopyWithInfo(elem, info.child(0, elem.Type()),
+		pointers, cfg)
 	if err != nil {
-		return reflect.Value{}, err
+		cfg.setPartial(dst.Elem(), elemDst)
+		return cfg.failed(dst, err)
+	}
+
+	if !cfg.dryRun {
+		if err := cfg.set(dst.Elem(), elemDst); err != nil {
+			return cfg.failed(dst, err)
+		}
+	}
+
+	return dst, nil
+}
+
+// recursiveCopyBuilder copies the *strings.Builder v by writing its contents
+// to a new builder. A builder records its address when written to, so the new
+// one is written to where it is allocated, which the copy never moves.
+func recursiveCopyBuilder(v reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	src := (*strings.Builder)(v.UnsafePointer())
+
+	cfg.countSize(int64(builderType.Size()) + int64(src.Len()))
+	dst := reflect.Zero(v.Type())
+	if !cfg.dryRun {
+		builder := &strings.Builder{}
+		builder.WriteString(src.String())
+		dst = reflect.ValueOf(builder)
+		cfg.countAllocation()
 	}
 
-	dst.Elem().Set(elemDst)
+	pointers[pointersMapKey{ptr: v.Pointer(), typ: v.Type()}] = dst
 
 	return dst, nil
 }
 
-func recursiveCopySlice(v reflect.Value, pointers pointersMap,
-	skipUnsupported bool) (reflect.Value, error) {
+func recursiveCopySlice(v reflect.Value, info *typeInfo,
+	pointers pointersMap, cfg *config) (reflect.Value, error) {
 	if v.IsNil() {
 		// If the slice is nil, just return it.
 		return v, nil
 	}
 
-	dst := reflect.MakeSlice(v.Type(), v.Len(), v.Cap())
+	// Slice headers built with package unsafe might be invalid, which would
+	// make the copy panic.
+	if v.Len() < 0 || v.Cap() < v.Len() {
+		return cfg.handleError(v, &InvalidSliceError{Type: v.Type(),
+			Len: v.Len(), Cap: v.Cap()})
+	}
 
-	for i := 0; i < v.Len(); i++ {
-		elem := v.Index(i)
-		elemDst, err := recursiveCopy(elem, pointers,
-			skipUnsupported)
+	// A slice can reach itself through an interface value, so it is
+	// memoized by its header. Zero capacity slices have no backing array to
+	// share and are never memoized.
+	key := pointersMapKey{ptr: v.Pointer(), typ: v.Type(), len: v.Len(),
+		cap: v.Cap()}
+	if key.cap > 0 {
+		if dst, ok := pointers[key]; ok {
+			cfg.countDeduped()
+			cfg.trace(TraceDedupHit, v.Type())
+			return dst, nil
+		}
+	}
+
+	sharesBacking := cfg.sharedBackingArrays && !cfg.dryRun && key.cap > 0 &&
+		v.Type().Elem().Size() > 0
+	if sharesBacking {
+		if dst, ok := sharedBacking(v, pointers); ok {
+			pointers[key] = dst
+			cfg.countDeduped()
+			cfg.trace(TraceDedupHit, v.Type())
+			return dst, nil
+		}
+	}
+
+	cfg.countSize(int64(v.Cap()) * int64(v.Type().Elem().Size()))
+	// The elements in [len:cap) are only copied if requested, or if other
+	// slices may share them. Reslicing is required to be able to access them.
+	full := cfg.copyFullCapacity || sharesBacking
+	dst := reflect.Zero(v.Type())
+	if !cfg.dryRun {
+		var err error
+		dst, err = cfg.makeSlice(v, full)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	if key.cap > 0 {
+		pointers[key] = dst
+	}
+
+	srcElems, dstElems := v, dst
+	if full {
+		var err error
+		srcElems, err = fullCapacity(v)
 		if err != nil {
 			return reflect.Value{}, err
 		}
 
-		dst.Index(i).Set(elemDst)
+		if !cfg.dryRun {
+			dstElems = dst.Slice(0, dst.Cap())
+		}
+	}
+
+	if cfg.interiorPointers && !cfg.dryRun {
+		recordElemPointers(srcElems, dstElems, pointers)
+	}
+
+	if sharesBacking {
+		pointers[backingKey(v)] = dstElems.Convert(
+			reflect.SliceOf(v.Type().Elem()))
+	}
+
+	// Elements are plain values, so they can all be copied at once. They
+	// still count as visited.
+	if cfg.canBulkCopy(v.Type().Elem()) {
+		if err := cfg.visit(srcElems.Len()); err != nil {
+			return reflect.Value{}, err
+		}
+
+		if !cfg.dryRun {
+			reflect.Copy(dstElems, srcElems)
+		}
+		return dst, nil
+	}
+
+	// The node budget is shared by the whole copy, so it also disables
+	// parallel copies.
+	if cfg.parallel > 1 && cfg.maxNodes == 0 && !cfg.dryRun &&
+		cfg.inRoot() && srcElems.Len() > 1 &&
+		!hasReferences(v.Type().Elem()) {
+		// Only the top-level slice is copied in parallel, and only when its
+		// elements can not share anything through the pointers map.
+		if err := copySliceElemsParallel(dstElems, srcElems, cfg); err != nil {
+			return cfg.failed(dst, err)
+		}
+
+		return dst, nil
+	}
+
+	elemInfo := info.child(0, v.Type().Elem())
+	for i := 0; i < srcElems.Len(); i++ {
+		elem := srcElems.Index(i)
+		cfg.pushIndex(i)
+		elemDst, err := recursiveCopyWithInfo(elem, elemInfo, pointers, cfg)
+		if err != nil {
+			cfg.popPath()
+			if elemDst.IsValid() {
+				dstElems.Index(i).Set(elemDst)
+			}
+			return cfg.failed(dst, inIndex(err, i))
+		}
+
+		if !cfg.dryRun {
+			err = cfg.set(dstElems.Index(i), elemDst)
+		}
+		cfg.popPath()
+		if err != nil {
+			return cfg.failed(dst, inIndex(err, i))
+		}
 	}
 
 	return dst, nil
 }
 
-func recursiveCopyStruct(v reflect.Value, pointers pointersMap,
-	skipUnsupported bool) (reflect.Value, error) {
-	dst := reflect.New(v.Type()).Elem()
+// makeSlice returns the slice the copy of v is made into, with the length of v,
+// and at least its capacity if full is true. That is the one given to
+// WithInPlaceSliceReuse when v is the top-level slice and it has enough
+// capacity, or a new one with the capacity of v.
+func (cfg *config) makeSlice(v reflect.Value, full bool) (reflect.Value, error) {
+	if cfg.reuseSlice.IsValid() && cfg.inRoot() {
+		existing := cfg.reuseSlice
+		if existing.Type().Elem() != v.Type().Elem() {
+			return reflect.Value{}, cfg.incompatible(v.Type(), existing)
+		}
 
-	t, ok := v.Interface().(time.Time)
-	if ok {
-		dst.Set(reflect.ValueOf(t))
-		return dst, nil
+		needed := v.Len()
+		if full {
+			needed = v.Cap()
+		}
+
+		if existing.Cap() >= needed {
+			existing = existing.Convert(v.Type())
+			if full {
+				return existing.Slice3(0, v.Len(), v.Cap()), nil
+			}
+
+			return existing.Slice(0, v.Len()), nil
+		}
+	}
+
+	cfg.countAllocation()
+
+	return reflect.MakeSlice(v.Type(), v.Len(), v.Cap()), nil
+}
+
+// recordElemPointers records the addresses of the elements of src in the
+// pointers map, so that pointers to them found later point to the matching
+// elements of dst. Pointers found before are not replaced, as they already
+// have their own copy.
+func recordElemPointers(src, dst reflect.Value, pointers pointersMap) {
+	typ := reflect.PointerTo(src.Type().Elem())
+	for i := 0; i < src.Len(); i++ {
+		key := pointersMapKey{ptr: src.Index(i).UnsafeAddr(), typ: typ}
+		if _, ok := pointers[key]; !ok {
+			pointers[key] = dst.Index(i).Addr()
+		}
+	}
+}
+
+// backingKey returns the key recording the copy of the backing array of the
+// slice v in the pointers map. Backing arrays are identified by where they
+// end, which is the same for all the slices of an array that extend up to its
+// end, and by their element type.
+func backingKey(v reflect.Value) pointersMapKey {
+	end := v.Pointer() + uintptr(v.Cap())*v.Type().Elem().Size()
+
+	return pointersMapKey{ptr: end, typ: reflect.SliceOf(v.Type().Elem()),
+		len: -1, cap: -1}
+}
+
+// sharedBacking returns the copy of v as a slice of the copy of its backing
+// array, if one that starts at or before v was already copied.
+func sharedBacking(v reflect.Value, pointers pointersMap) (reflect.Value, bool) {
+	key := backingKey(v)
+	backing, ok := pointers[key]
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	start := key.ptr - uintptr(backing.Cap())*v.Type().Elem().Size()
+	if v.Pointer() < start {
+		return reflect.Value{}, false
+	}
+
+	offset := int((v.Pointer() - start) / v.Type().Elem().Size())
+	dst := backing.Slice3(offset, offset+v.Len(), offset+v.Cap())
+
+	return dst.Convert(v.Type()), true
+}
+
+// fullCapacity returns v resliced up to its capacity. Reslicing does not
+// panic for valid slice headers, but headers built with package unsafe could
+// still make it panic, which is reported as an error instead.
+func fullCapacity(v reflect.Value) (full reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &InvalidSliceError{Type: v.Type(), Len: v.Len(), Cap: v.Cap()}
+		}
+	}()
+
+	return v.Slice(0, v.Cap()), nil
+}
+
+// recursiveCopySpecialStruct copies the value v of one of the struct types
+// that are copied with their own semantics instead of field by field into
+// dst. It reports false if v has to be copied field by field anyway (e.g.
+// because it was obtained through unexported fields).
+func recursiveCopySpecialStruct(v, dst reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, bool, error) {
+	var src any
+	if v.CanInterface() {
+		src = v.Interface()
+	}
+
+	switch src := src.(type) {
+	case time.Time:
+		// A value copy preserves the wall clock and monotonic readings. The
+		// *time.Location is intentionally shared, as locations are immutable.
+		if cfg.timeInUTC {
+			src = src.UTC()
+		}
+		dst.Set(reflect.ValueOf(src))
+		return dst, true, nil
+	case big.Int:
+		// The big types keep their digits in unexported slices, so their own
+		// copy semantics are used to get an independent value.
+		dst.Set(reflect.ValueOf(new(big.Int).Set(&src)).Elem())
+		return dst, true, nil
+	case big.Rat:
+		dst.Set(reflect.ValueOf(new(big.Rat).Set(&src)).Elem())
+		return dst, true, nil
+	case big.Float:
+		dst.Set(reflect.ValueOf(new(big.Float).Copy(&src)).Elem())
+		return dst, true, nil
+	case reflect.Value:
+		// The fields of reflect.Value are unexported, so the value it holds
+		// is copied instead and wrapped again. That copy may be the source
+		// itself (for plain values) or a pooled temporary, so it is moved to
+		// memory of its own first.
+		if src.IsValid() && src.CanInterface() {
+			copied, err := recursiveCopy(src, pointers, cfg)
+			if err != nil {
+				return reflect.Value{}, true, err
+			}
+
+			if copied.IsValid() {
+				detached := reflect.New(src.Type()).Elem()
+				if err := cfg.set(detached, copied); err != nil {
+					return reflect.Value{}, true, err
+				}
+				copied = detached
+			}
+			src = copied
+		}
+		dst.Set(reflect.ValueOf(src))
+		return dst, true, nil
+	case bytes.Buffer:
+		// Only the unread portion is kept, as bytes.NewBuffer would.
+		buf := bytes.NewBuffer(append([]byte(nil), src.Bytes()...))
+		dst.Set(reflect.ValueOf(buf).Elem())
+		return dst, true, nil
+	case strings.Builder:
+		// A builder records its address when written to, to detect copies
+		// by value, and copied values are moved to where they end up
+		// afterwards. So only empty builders can be copied as values, while
+		// pointers to builders are rebuilt where they point to.
+		if src.Len() > 0 {
+			dst, err := cfg.unsupported(v, &UnsupportedTypeError{Type: v.Type()})
+			return dst, true, err
+		}
+		return dst, true, nil
+	case url.Userinfo:
+		// The user name and password are unexported, so the value has to be
+		// rebuilt from them.
+		user := url.User(src.Username())
+		if password, ok := src.Password(); ok {
+			user = url.UserPassword(src.Username(), password)
+		}
+		dst.Set(reflect.ValueOf(user).Elem())
+		return dst, true, nil
+	}
+
+	if isLinkedContainer(v) {
+		// Only pointers to lists and rings can be copied, as copying the
+		// value would leave it linked to the source.
+		dst, err := cfg.unsupported(v, &UnsupportedTypeError{Type: v.Type()})
+		return dst, true, err
 	}
 
-	for i := 0; i < v.NumField(); i++ {
-		elem := v.Field(i)
+	if _, ok := resetTypes[v.Type()]; ok {
+		// dst already holds the zero value for the type.
+		return dst, true, nil
+	}
+
+	if isAtomicType(v.Type()) {
+		dst, err := recursiveCopyAtomic(v, dst, pointers, cfg)
+		return dst, true, err
+	}
+
+	if v.Type() == syncMapType {
+		dst, err := recursiveCopySyncMap(v, dst, pointers, cfg)
+		return dst, true, err
+	}
+
+	return reflect.Value{}, false, nil
+}
+
+func recursiveCopyStruct(v reflect.Value, info *typeInfo,
+	pointers pointersMap, cfg *config) (reflect.Value, error) {
+	cfg.trace(TraceEnterStruct, v.Type())
+	dst := newTemporary(v.Type(), cfg)
+
+	if info.special {
+		if dst, ok, err := recursiveCopySpecialStruct(v, dst, pointers,
+			cfg); ok {
+			return dst, err
+		}
+	}
+
+	unexported := cfg.copiesUnexportedFields(v.Type())
+	if unexported {
+		// Unexported fields are accessed through their address.
+		v = addressable(v)
+	}
+
+	shareTrivial := cfg.canShareTrivial()
+	for _, field := range info.fields {
+		if field.directive == fieldInvalid && !cfg.ignoreBadTags {
+			err := &InvalidTagError{Type: v.Type(), Field: field.name,
+				Tag: field.tag}
+			return cfg.failed(dst, inField(err, field.name))
+		}
+
+		if cfg.skipField != nil {
+			cfg.pushField(field.name)
+			skip := cfg.skipField(cfg.currentPath(), v.Type().Field(field.index))
+			cfg.popPath()
+			if skip {
+				// The field is left with the zero value for its type.
+				continue
+			}
+		}
 
-		// The Type's StructField for a given field is checked to see if StructField.PkgPath
-		// is set to determine if the field is exported or not because CanSet() returns false
-		// for settable fields
-		if v.Type().Field(i).PkgPath != "" {
+		if !field.exported && !unexported {
+			// Blank fields are only padding, so nothing is lost by skipping
+			// them.
+			if cfg.errorOnUnexported && field.name != "_" {
+				err := &UnexportedFieldError{Type: v.Type(), Field: field.name}
+				return cfg.failed(dst, inField(err, field.name))
+			}
 			continue
 		}
 
-		elemDst, err := recursiveCopy(elem, pointers,
-			skipUnsupported)
-		if err != nil {
-			return reflect.Value{}, err
+		elem := v.Field(field.index)
+		dstField := dst.Field(field.index)
+		if !field.exported {
+			elem = exposeField(elem)
+			dstField = exposeField(dstField)
+		}
+
+		switch field.directive {
+		case fieldSkip:
+			// The field is left with the zero value for its type.
+			continue
+		case fieldShallow:
+			// The field is shared by reference with the source.
+			dstField.Set(elem)
+			continue
+		}
+
+		fieldInfo := info.child(field.index, elem.Type())
+		if shareTrivial && fieldInfo.trivial {
+			// Plain values are their own copy, as recursiveCopy would find
+			// too.
+			dstField.Set(elem)
+			continue
 		}
 
-		dstField := dst.Field(i)
+		cfg.pushField(field.name)
+		elemDst, err := recursiveCopyWithInfo(elem, fieldInfo, pointers, cfg)
+		if err != nil {
+			cfg.popPath()
+			cfg.setPartial(dstField, elemDst)
+			return cfg.failed(dst, inField(err, field.name))
+		}
 
-		dstField.Set(elemDst)
+		err = cfg.set(dstField, elemDst)
+		cfg.popPath()
+		if err != nil {
+			return cfg.failed(dst, inField(err, field.name))
+		}
 	}
 
 	return dst, nil
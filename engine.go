@@ -0,0 +1,80 @@
+package deep
+
+import "sync"
+
+// Engine is a reusable copier with a fixed set of options, so they do not have
+// to be given again for every copy. An Engine is safe for concurrent use. The
+// results of WithStats and WithSkipReport are collected separately by each
+// copy and added to the given Stats or report when it completes, so they hold
+// the results of all the copies done so far and must only be read once those
+// are done. The slice given to WithInPlaceSliceReuse and the value given to
+// WithPartialOnError are written to by every copy, so engines given those
+// must not be used concurrently.
+type Engine struct {
+	// cfg is the template every copy starts from. It is never modified after
+	// NewEngine returns.
+	cfg config
+
+	// mu guards the Stats and skip report the results of each copy are added
+	// to.
+	mu sync.Mutex
+}
+
+// NewEngine returns an Engine that copies with the given options.
+func NewEngine(opts ...Option) *Engine {
+	return &Engine{cfg: *newConfig(opts)}
+}
+
+// newConfig returns a fresh config for a single copy done with the engine.
+// The copy collects its statistics and skipped fields on its own, and they
+// are added to those of the engine by done.
+func (e *Engine) newConfig() *config {
+	cfg := e.cfg
+	if e.cfg.stats != nil {
+		cfg.stats = &Stats{}
+	}
+	if e.cfg.skipReport != nil {
+		cfg.skipReport = &[]SkippedField{}
+	}
+
+	return &cfg
+}
+
+// done adds the statistics and skipped fields collected by the copy done with
+// cfg to those of the engine.
+func (e *Engine) done(cfg *config) {
+	if cfg.stats == nil && cfg.skipReport == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if cfg.stats != nil {
+		e.cfg.stats.add(*cfg.stats)
+	}
+	if cfg.skipReport != nil {
+		*e.cfg.skipReport = append(*e.cfg.skipReport, *cfg.skipReport...)
+	}
+}
+
+// CopyWithEngine creates a deep copy of src using the options of the given
+// engine. It returns the copy and a nil error in case of success and the zero
+// value for the type and a non-nil error on failure.
+func CopyWithEngine[T any](e *Engine, src T) (T, error) {
+	cfg := e.newConfig()
+	defer e.done(cfg)
+
+	return copyInternal(src, cfg)
+}
+
+// MustCopyWithEngine creates a deep copy of src using the options of the given
+// engine. It returns the copy on success or panics in case of any failure.
+func MustCopyWithEngine[T any](e *Engine, src T) T {
+	dst, err := CopyWithEngine(e, src)
+	if err != nil {
+		panic(err)
+	}
+
+	return dst
+}
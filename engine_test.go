@@ -0,0 +1,150 @@
+package deep
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCopyWithEngine(t *testing.T) {
+	type S struct {
+		A int
+		B func()
+		C *int
+	}
+
+	c := 42
+	src := S{A: 42, B: func() {}, C: &c}
+
+	e := NewEngine(WithSkipUnsupported())
+
+	dst, err := CopyWithEngine(e, src)
+	if err != nil {
+		t.Fatalf("CopyWithEngine failed: %v", err)
+	}
+
+	if dst.A != 42 || dst.B != nil || *dst.C != 42 || dst.C == src.C {
+		t.Errorf("Unexpected copy: %+v", dst)
+	}
+
+	if _, err := CopyWithEngine(NewEngine(), src); err == nil {
+		t.Errorf("CopyWithEngine without options did not fail")
+	}
+}
+
+func TestCopyWithEngine_Reuse(t *testing.T) {
+	e := NewEngine(WithMaxDepth(4))
+
+	// State from one copy (e.g. the current depth) must not leak into the
+	// next one.
+	for i := 0; i < 10; i++ {
+		if _, err := CopyWithEngine(e, newDepthList(2)); err != nil {
+			t.Fatalf("CopyWithEngine failed on copy %d: %v", i, err)
+		}
+	}
+
+	if _, err := CopyWithEngine(e, newDepthList(3)); err == nil {
+		t.Errorf("CopyWithEngine did not enforce the maximum depth")
+	}
+}
+
+func TestCopyWithEngine_Concurrent(t *testing.T) {
+	type S struct {
+		A []int
+		B map[string]*int
+	}
+
+	b := 1
+	src := S{A: []int{1, 2, 3}, B: map[string]*int{"b": &b}}
+
+	e := NewEngine(WithShareFuncs())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			dst, err := CopyWithEngine(e, src)
+			if err != nil {
+				t.Errorf("CopyWithEngine failed: %v", err)
+				return
+			}
+
+			if !reflect.DeepEqual(dst, src) {
+				t.Errorf("Expected %v, got %v", src, dst)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestCopyWithEngine_ConcurrentResults(t *testing.T) {
+	type S struct {
+		A []int
+		F func()
+	}
+
+	src := S{A: []int{1, 2, 3}, F: func() {}}
+
+	var stats Stats
+	var report []SkippedField
+	e := NewEngine(WithSkipUnsupported(), WithStats(&stats),
+		WithSkipReport(&report))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := CopyWithEngine(e, src); err != nil {
+				t.Errorf("CopyWithEngine failed: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var single Stats
+	if _, err := Copy(src, WithSkipUnsupported(), WithStats(&single)); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if stats.Nodes != 16*single.Nodes || stats.MaxDepth != single.MaxDepth {
+		t.Errorf("Expected 16 times %+v, got %+v", single, stats)
+	}
+	if len(report) != 16 {
+		t.Errorf("Expected 16 skipped fields, got %v", report)
+	}
+}
+
+func TestMustCopyWithEngine(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("MustCopyWithEngine did not panic")
+		}
+	}()
+
+	MustCopyWithEngine(NewEngine(), func() {})
+}
+
+func BenchmarkCopy_Options(b *testing.B) {
+	src := newPooledOuter()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src, WithSkipUnsupported(), WithMaxDepth(16), WithShareFuncs())
+	}
+}
+
+func BenchmarkCopy_Engine(b *testing.B) {
+	src := newPooledOuter()
+	e := NewEngine(WithSkipUnsupported(), WithMaxDepth(16), WithShareFuncs())
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MustCopyWithEngine(e, src)
+	}
+}
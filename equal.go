@@ -0,0 +1,198 @@
+package deep
+
+import (
+	"maps"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Equal reports whether a and b are structurally identical in the sense used
+// by the copy functions, so Equal(src, MustCopy(src)) is true for any src that
+// can be copied with the default options. It differs from reflect.DeepEqual in
+// the following ways:
+//
+//   - Unexported struct fields are ignored, as they are not copied by
+//     default.
+//   - Maps with keys holding references (e.g. pointers) are matched by deep
+//     equality of their keys, as keys are deep copied.
+//   - time.Time values are compared with ==, big numbers with Cmp, and
+//     sync primitives are always considered equal, mirroring how they are
+//     copied.
+//   - Floating point NaNs are equal to each other, as the copy of a NaN is
+//     the same NaN.
+//
+// Cycles are handled by tracking visited pairs of pointers, maps and slices.
+// Like reflect.DeepEqual, a nil slice or map is not equal to an empty one, and
+// functions are only equal if both are nil.
+func Equal[T any](a, b T) bool {
+	return deepEqual(reflect.ValueOf(a), reflect.ValueOf(b), make(visitedPairs))
+}
+
+type visitedPair struct {
+	a, b uintptr
+	typ  reflect.Type
+	// len is only set for slices, as slices sharing a backing array can
+	// differ in length.
+	len int
+}
+type visitedPairs map[visitedPair]struct{}
+
+func deepEqual(a, b reflect.Value, visited visitedPairs) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Map, reflect.Ptr, reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+
+		pair := visitedPair{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+		if a.Kind() == reflect.Slice {
+			if a.Len() != b.Len() {
+				return false
+			}
+			pair.len = a.Len()
+		}
+		if _, ok := visited[pair]; ok {
+			// Already being compared further up, so assume equality.
+			return true
+		}
+		visited[pair] = struct{}{}
+	}
+
+	switch a.Kind() {
+	case reflect.Array:
+		return deepEqualElems(a, b, visited)
+	case reflect.Slice:
+		return deepEqualElems(a, b, visited)
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return deepEqual(a.Elem(), b.Elem(), visited)
+	case reflect.Map:
+		return deepEqualMap(a, b, visited)
+	case reflect.Ptr:
+		return deepEqual(a.Elem(), b.Elem(), visited)
+	case reflect.Struct:
+		return deepEqualStruct(a, b, visited)
+	case reflect.Func:
+		return a.IsNil() && b.IsNil()
+	case reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Float32, reflect.Float64:
+		return floatEqual(a.Float(), b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return floatEqual(real(a.Complex()), real(b.Complex())) &&
+			floatEqual(imag(a.Complex()), imag(b.Complex()))
+	default:
+		return a.Equal(b)
+	}
+}
+
+// floatEqual reports whether a and b are equal, or both NaN.
+func floatEqual(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+func deepEqualElems(a, b reflect.Value, visited visitedPairs) bool {
+	for i := 0; i < a.Len(); i++ {
+		if !deepEqual(a.Index(i), b.Index(i), visited) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func deepEqualMap(a, b reflect.Value, visited visitedPairs) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	if !hasReferences(a.Type().Key()) {
+		iter := a.MapRange()
+		for iter.Next() {
+			bElem := b.MapIndex(iter.Key())
+			if !bElem.IsValid() ||
+				!deepEqual(iter.Value(), bElem, visited) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	// Keys holding references were deep copied, so they must be matched by
+	// deep equality instead of by map lookup.
+	matched := make(map[int]struct{}, b.Len())
+	bKeys := b.MapKeys()
+	for _, aKey := range a.MapKeys() {
+		found := false
+		for i, bKey := range bKeys {
+			if _, ok := matched[i]; ok {
+				continue
+			}
+
+			// Pairs assumed equal while trying a key that turns out not to
+			// match must be forgotten, so they are recorded in a scratch
+			// copy that is only kept if it does.
+			trial := maps.Clone(visited)
+			if deepEqual(aKey, bKey, trial) &&
+				deepEqual(a.MapIndex(aKey), b.MapIndex(bKey), trial) {
+				maps.Copy(visited, trial)
+				matched[i] = struct{}{}
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func deepEqualStruct(a, b reflect.Value, visited visitedPairs) bool {
+	if _, ok := resetTypes[a.Type()]; ok {
+		return true
+	}
+
+	if a.CanInterface() {
+		switch aVal := a.Interface().(type) {
+		case time.Time:
+			return aVal == b.Interface().(time.Time)
+		case big.Int:
+			bVal := b.Interface().(big.Int)
+			return aVal.Cmp(&bVal) == 0
+		case big.Rat:
+			bVal := b.Interface().(big.Rat)
+			return aVal.Cmp(&bVal) == 0
+		case big.Float:
+			bVal := b.Interface().(big.Float)
+			return aVal.Cmp(&bVal) == 0
+		}
+	}
+
+	for _, field := range structFields(a.Type()) {
+		if !field.exported {
+			continue
+		}
+
+		if !deepEqual(a.Field(field.index), b.Field(field.index), visited) {
+			return false
+		}
+	}
+
+	return true
+}
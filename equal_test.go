@@ -0,0 +1,197 @@
+package deep
+
+import (
+	"math"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEqual_Scalars(t *testing.T) {
+	if !Equal(42, 42) {
+		t.Errorf("Expected equal ints to be equal")
+	}
+
+	if Equal(42, 43) {
+		t.Errorf("Expected different ints to not be equal")
+	}
+
+	if !Equal("42", "42") {
+		t.Errorf("Expected equal strings to be equal")
+	}
+}
+
+func TestEqual_NilVsEmpty(t *testing.T) {
+	var nilSlice []int
+	if !Equal(nilSlice, nil) {
+		t.Errorf("Expected two nil slices to be equal")
+	}
+
+	if Equal(nilSlice, []int{}) {
+		t.Errorf("Expected nil and empty slices to not be equal")
+	}
+
+	var nilMap map[string]int
+	if Equal(nilMap, map[string]int{}) {
+		t.Errorf("Expected nil and empty maps to not be equal")
+	}
+}
+
+func TestEqual_Interfaces(t *testing.T) {
+	if !Equal[any](nil, nil) {
+		t.Errorf("Expected nil interfaces to be equal")
+	}
+
+	if Equal[any](42, "42") {
+		t.Errorf("Expected interfaces with different dynamic types to not be equal")
+	}
+}
+
+func TestEqual_Cycle(t *testing.T) {
+	type Node struct {
+		Value int
+		Next  *Node
+	}
+
+	a := &Node{Value: 1}
+	a.Next = &Node{Value: 2, Next: a}
+
+	b := &Node{Value: 1}
+	b.Next = &Node{Value: 2, Next: b}
+
+	if !Equal(a, b) {
+		t.Errorf("Expected equal cyclic structures to be equal")
+	}
+
+	b.Next.Value = 3
+	if Equal(a, b) {
+		t.Errorf("Expected different cyclic structures to not be equal")
+	}
+}
+
+func TestEqual_CyclicSlice(t *testing.T) {
+	a := []any{nil, 1}
+	a[0] = a
+
+	b := []any{nil, 1}
+	b[0] = b
+
+	if !Equal(a, b) {
+		t.Errorf("Expected equal cyclic slices to be equal")
+	}
+}
+
+func TestEqual_SharedBackingArray(t *testing.T) {
+	type S struct {
+		X, Y []int
+	}
+
+	// Slices sharing a backing array are compared by their own length, even
+	// if another pair of slices over the same arrays was compared already.
+	a := []int{1, 2}
+	if Equal(S{X: a[:1], Y: a[:1]}, S{X: a[:1], Y: a}) {
+		t.Errorf("Expected slices of different lengths to not be equal")
+	}
+
+	b := []int{1, 3}
+	if Equal(S{X: a[:1], Y: a}, S{X: b[:1], Y: b}) {
+		t.Errorf("Expected slices with different elements to not be equal")
+	}
+}
+
+func TestEqual_Copy(t *testing.T) {
+	type Node struct {
+		Name     string
+		Children []*Node
+		Parent   *Node
+		Attrs    map[*int]string
+		Created  time.Time
+		Total    *big.Int
+		Mu       sync.Mutex
+		private  int
+	}
+
+	key := 1
+	root := &Node{Name: "root", Attrs: map[*int]string{&key: "one"},
+		Created: time.Now(), Total: big.NewInt(42), private: 1}
+	child := &Node{Name: "child", Parent: root}
+	root.Children = []*Node{child, child}
+
+	dst := MustCopy(root)
+
+	if !Equal(root, dst) {
+		t.Errorf("Expected copy to be equal to source")
+	}
+
+	dst.Children[0].Name = "changed"
+	if Equal(root, dst) {
+		t.Errorf("Expected modified copy to not be equal to source")
+	}
+}
+
+func TestEqual_UnexportedFieldsIgnored(t *testing.T) {
+	type S struct {
+		A int
+		b int
+	}
+
+	if !Equal(S{A: 1, b: 1}, S{A: 1, b: 2}) {
+		t.Errorf("Expected unexported fields to be ignored")
+	}
+}
+
+func TestEqual_Funcs(t *testing.T) {
+	var f func()
+	if !Equal(f, nil) {
+		t.Errorf("Expected nil funcs to be equal")
+	}
+
+	g := func() {}
+	if Equal(g, g) {
+		t.Errorf("Expected non-nil funcs to not be equal")
+	}
+}
+
+func TestEqual_PointerKeyedMapCandidates(t *testing.T) {
+	type S struct {
+		M map[*int]int
+		P *int
+	}
+
+	p, r := 1, 2
+	q1, q2 := 2, 1
+	a := S{M: map[*int]int{&p: 10, &r: 20}, P: &p}
+	b := S{M: map[*int]int{&q1: 20, &q2: 10}, P: &q1}
+
+	// Trying the keys of b in a different order must not leave p and q1
+	// assumed equal, whatever the map iteration order is.
+	for i := 0; i < 100; i++ {
+		if Equal(a, b) {
+			t.Fatalf("Expected structures with different P to not be equal")
+		}
+	}
+
+	b.P = &q2
+	if !Equal(a, b) {
+		t.Errorf("Expected equal structures to be equal")
+	}
+}
+
+func TestEqual_NaN(t *testing.T) {
+	type S struct {
+		F float64
+		C complex64
+		A []float32
+	}
+
+	nan := math.NaN()
+	src := S{F: nan, C: complex(float32(nan), 1), A: []float32{float32(nan)}}
+
+	if !Equal(src, MustCopy(src)) {
+		t.Errorf("Expected the copy of NaNs to be equal to the source")
+	}
+	if Equal(src, S{F: 1, C: src.C, A: src.A}) {
+		t.Errorf("Expected NaN to not be equal to a number")
+	}
+}
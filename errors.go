@@ -0,0 +1,202 @@
+package deep
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNilDestination is returned when copying into a nil destination pointer.
+var ErrNilDestination = errors.New("nil destination")
+
+// UnsupportedTypeError is returned when a value that can not be copied is
+// found. Use errors.As to inspect it.
+type UnsupportedTypeError struct {
+	// Type is the type of the value that could not be copied.
+	Type reflect.Type
+	// Path is the location of the value relative to the root value being
+	// copied (e.g. `Items[2].Callback`). It is empty for the root value.
+	Path string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	var msg string
+	switch e.Type.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		msg = fmt.Sprintf("unsupported non-nil value for type: %s", e.Type)
+	default:
+		msg = fmt.Sprintf("unsupported type: %s", e.Type)
+	}
+
+	return withPath(msg, e.Path)
+}
+
+// MaxDepthError is returned when the value being copied is nested more deeply
+// than allowed by WithMaxDepth.
+type MaxDepthError struct {
+	// MaxDepth is the maximum depth that was exceeded.
+	MaxDepth int
+	// Type is the type of the value that would exceed the maximum depth.
+	Type reflect.Type
+	// Path is the location of the value relative to the root value being
+	// copied.
+	Path string
+}
+
+func (e *MaxDepthError) Error() string {
+	return withPath(fmt.Sprintf("maximum depth of %d exceeded for type: %s",
+		e.MaxDepth, e.Type), e.Path)
+}
+
+// MaxNodesError is returned when copying would visit more values than allowed
+// by WithMaxNodes.
+type MaxNodesError struct {
+	// MaxNodes is the maximum number of values that was exceeded.
+	MaxNodes int
+	// Path is the location of the first value over the limit relative to the
+	// root value being copied.
+	Path string
+}
+
+func (e *MaxNodesError) Error() string {
+	return withPath(fmt.Sprintf("maximum of %d copied values exceeded",
+		e.MaxNodes), e.Path)
+}
+
+// UnexportedFieldError is returned by copies using WithErrorOnUnexported when
+// a struct with an unexported field that would be skipped is found.
+type UnexportedFieldError struct {
+	// Type is the type of the struct holding the field.
+	Type reflect.Type
+	// Field is the name of the unexported field.
+	Field string
+	// Path is the location of the field relative to the root value being
+	// copied.
+	Path string
+}
+
+func (e *UnexportedFieldError) Error() string {
+	return withPath(fmt.Sprintf("unexported field %s of type %s can not be copied",
+		e.Field, e.Type), e.Path)
+}
+
+// IncompatibleValueError is returned when a copy can not be stored in place of
+// the source value, which can only happen when custom copy logic (like a
+// Copier implementation, or a WithTransform or WithInterfaceFactory function)
+// returns an invalid value or one of the wrong type, or when a value given to
+// an option (like WithFieldDefault) does not fit.
+type IncompatibleValueError struct {
+	// Type is the type the copy had to be stored as.
+	Type reflect.Type
+	// ValueType is the type of the copy, or nil if it was an invalid value
+	// (e.g. a nil interface).
+	ValueType reflect.Type
+	// Path is the location of the value relative to the root value being
+	// copied.
+	Path string
+}
+
+func (e *IncompatibleValueError) Error() string {
+	if e.ValueType == nil {
+		return withPath(fmt.Sprintf("invalid value can not be used as a copy of type %s",
+			e.Type), e.Path)
+	}
+
+	return withPath(fmt.Sprintf("value of type %s can not be used as a copy of type %s",
+		e.ValueType, e.Type), e.Path)
+}
+
+// InvalidTagError is returned when a struct field has a `deep` tag with an
+// unrecognized directive, unless WithIgnoreBadTags is given.
+type InvalidTagError struct {
+	// Type is the type of the struct holding the field.
+	Type reflect.Type
+	// Field is the name of the field.
+	Field string
+	// Tag is the value of the `deep` tag.
+	Tag string
+	// Path is the location of the field relative to the root value being
+	// copied.
+	Path string
+}
+
+func (e *InvalidTagError) Error() string {
+	return withPath(fmt.Sprintf("invalid deep tag %q on field %s of type %s",
+		e.Tag, e.Field, e.Type), e.Path)
+}
+
+// InvalidSliceError is returned when a slice with an invalid header (e.g. a
+// capacity smaller than its length), which can only be built with package
+// unsafe, is found.
+type InvalidSliceError struct {
+	// Type is the type of the slice.
+	Type reflect.Type
+	// Len and Cap are the length and capacity in the slice header.
+	Len, Cap int
+	// Path is the location of the slice relative to the root value being
+	// copied.
+	Path string
+}
+
+func (e *InvalidSliceError) Error() string {
+	return withPath(fmt.Sprintf("invalid slice of type %s with length %d and capacity %d",
+		e.Type, e.Len, e.Cap), e.Path)
+}
+
+// ResourceError is returned by copies using WithStrictResources when a value
+// holding a resource that can not be meaningfully copied is found.
+type ResourceError struct {
+	// Type is the type of the value holding the resource.
+	Type reflect.Type
+	// Path is the location of the value relative to the root value being
+	// copied.
+	Path string
+}
+
+func (e *ResourceError) Error() string {
+	return withPath(fmt.Sprintf("value of type %s holds a resource that can not be copied",
+		e.Type), e.Path)
+}
+
+// BinaryRoundTripError is returned when a value of a type given to
+// WithBinaryRoundTrip can not be copied by marshaling and unmarshaling it.
+type BinaryRoundTripError struct {
+	// Type is the type of the value.
+	Type reflect.Type
+	// Path is the location of the value relative to the root value being
+	// copied.
+	Path string
+	// Err is the error returned by MarshalBinary or UnmarshalBinary, or the
+	// one telling they are not implemented.
+	Err error
+}
+
+func (e *BinaryRoundTripError) Error() string {
+	return withPath(fmt.Sprintf("binary round trip failed for type %s: %v",
+		e.Type, e.Err), e.Path)
+}
+
+// Unwrap returns the underlying error.
+func (e *BinaryRoundTripError) Unwrap() error {
+	return e.Err
+}
+
+// pathRef returns the path of the errors that report one, so it can be built
+// while they are returned from the value they are about.
+func (e *UnsupportedTypeError) pathRef() *string   { return &e.Path }
+func (e *MaxDepthError) pathRef() *string          { return &e.Path }
+func (e *MaxNodesError) pathRef() *string          { return &e.Path }
+func (e *UnexportedFieldError) pathRef() *string   { return &e.Path }
+func (e *IncompatibleValueError) pathRef() *string { return &e.Path }
+func (e *InvalidTagError) pathRef() *string        { return &e.Path }
+func (e *InvalidSliceError) pathRef() *string      { return &e.Path }
+func (e *ResourceError) pathRef() *string          { return &e.Path }
+func (e *BinaryRoundTripError) pathRef() *string   { return &e.Path }
+
+func withPath(msg, path string) string {
+	if path == "" {
+		return msg
+	}
+
+	return msg + " at path: " + path
+}
@@ -0,0 +1,156 @@
+package deep
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestUnsupportedTypeError(t *testing.T) {
+	type Inner struct {
+		C chan int
+	}
+
+	type S struct {
+		Items []Inner
+	}
+
+	src := S{Items: []Inner{{}, {C: make(chan int)}}}
+
+	_, err := Copy(src)
+
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected an UnsupportedTypeError, got %v", err)
+	}
+
+	if unsupported.Type != reflect.TypeFor[chan int]() {
+		t.Errorf("Expected type chan int, got %s", unsupported.Type)
+	}
+
+	if unsupported.Path != "Items[1].C" {
+		t.Errorf("Expected path Items[1].C, got %q", unsupported.Path)
+	}
+
+	expected := "unsupported non-nil value for type: chan int at path: Items[1].C"
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestUnsupportedTypeError_MapKeyPath(t *testing.T) {
+	_, err := Copy(map[string]func(){"callback": func() {}})
+
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected an UnsupportedTypeError, got %v", err)
+	}
+
+	if unsupported.Path != `["callback"]` {
+		t.Errorf("Expected path [\"callback\"], got %q", unsupported.Path)
+	}
+}
+
+func TestUnsupportedTypeError_PathTracked(t *testing.T) {
+	type Inner struct {
+		Callbacks map[string][]func()
+	}
+
+	type S struct {
+		Items []*Inner
+	}
+
+	src := S{Items: []*Inner{{}, {Callbacks: map[string][]func(){
+		"a": {nil, func() {}}}}}}
+
+	// The path is only tracked during the copy for options that need it, and
+	// has to be the same either way.
+	fail := WithErrorHandler(func(string, reflect.Type, error) Decision {
+		return Fail
+	})
+	for _, opts := range [][]Option{nil, {fail}} {
+		_, err := Copy(src, opts...)
+
+		var unsupported *UnsupportedTypeError
+		if !errors.As(err, &unsupported) {
+			t.Fatalf("Expected an UnsupportedTypeError, got %v", err)
+		}
+
+		if unsupported.Path != `Items[1].Callbacks["a"][1]` {
+			t.Errorf("Expected path Items[1].Callbacks[\"a\"][1], got %q",
+				unsupported.Path)
+		}
+	}
+}
+
+func TestUnsupportedTypeError_Root(t *testing.T) {
+	_, err := Copy(func() {})
+
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected an UnsupportedTypeError, got %v", err)
+	}
+
+	if unsupported.Path != "" {
+		t.Errorf("Expected an empty path, got %q", unsupported.Path)
+	}
+
+	expected := "unsupported non-nil value for type: func()"
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestMaxDepthError(t *testing.T) {
+	_, err := Copy(newDepthList(3), WithMaxDepth(3))
+
+	var maxDepth *MaxDepthError
+	if !errors.As(err, &maxDepth) {
+		t.Fatalf("Expected a MaxDepthError, got %v", err)
+	}
+
+	if maxDepth.MaxDepth != 3 {
+		t.Errorf("Expected max depth 3, got %d", maxDepth.MaxDepth)
+	}
+
+	if maxDepth.Path != "Next" {
+		t.Errorf("Expected path Next, got %q", maxDepth.Path)
+	}
+}
+
+func TestErrNilDestination(t *testing.T) {
+	err := CopyInto[int](nil, 42)
+	if !errors.Is(err, ErrNilDestination) {
+		t.Errorf("Expected ErrNilDestination, got %v", err)
+	}
+}
+
+func TestInvalidSliceError(t *testing.T) {
+	type S struct {
+		Values []int
+	}
+
+	for _, opts := range [][]Option{nil, {WithCopyFullCapacity()}} {
+		src := S{Values: []int{1, 2, 3}}
+
+		// Make the length larger than the capacity.
+		header := (*struct {
+			data     unsafe.Pointer
+			len, cap int
+		})(unsafe.Pointer(&src.Values))
+		header.len = 5
+
+		_, err := Copy(src, opts...)
+
+		var invalid *InvalidSliceError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("Expected an InvalidSliceError, got %v", err)
+		}
+		if invalid.Len != 5 || invalid.Cap != 3 || invalid.Path != "Values" {
+			t.Errorf("Unexpected error: %+v", invalid)
+		}
+
+		header.len = 3
+	}
+}
@@ -0,0 +1,229 @@
+package deep
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldDirective is what the `deep` struct tag asks for a field.
+type fieldDirective int
+
+const (
+	// fieldCopy is the default, deep copying the field.
+	fieldCopy fieldDirective = iota
+	// fieldSkip leaves the field with the zero value for its type.
+	fieldSkip
+	// fieldShallow shares the field by reference with the source.
+	fieldShallow
+	// fieldInvalid is an unrecognized directive, which makes the copy fail
+	// unless WithIgnoreBadTags is given, in which case the field is copied
+	// like with fieldCopy.
+	fieldInvalid
+)
+
+// fieldInfo is the precomputed metadata for a single struct field.
+type fieldInfo struct {
+	index     int
+	name      string
+	exported  bool
+	directive fieldDirective
+	// tag is the `deep` struct tag, kept to report invalid directives.
+	tag string
+	// rename is the name of the field matching this one in the struct types
+	// CopyAs converts to, if it is not the same name.
+	rename string
+}
+
+// structFields returns the field metadata for the given struct type.
+func structFields(t reflect.Type) []fieldInfo {
+	return infoFor(t).fields
+}
+
+// fieldsOf computes the field metadata for the given struct type.
+func fieldsOf(t reflect.Type) []fieldInfo {
+	fields := make([]fieldInfo, t.NumField())
+	for i := range fields {
+		field := t.Field(i)
+		tag := field.Tag.Get("deep")
+
+		fields[i] = fieldInfo{
+			index: i,
+			name:  field.Name,
+			// The StructField's PkgPath is checked to see if the field is
+			// exported or not because CanSet() returns false for settable
+			// fields.
+			exported:  field.PkgPath == "",
+			directive: parseFieldDirective(tag),
+			tag:       tag,
+		}
+		if name, ok := strings.CutPrefix(tag, "rename="); ok && name != "" {
+			fields[i].rename = name
+		}
+	}
+
+	return fields
+}
+
+func parseFieldDirective(tag string) fieldDirective {
+	switch tag {
+	case "-":
+		return fieldSkip
+	case "shallow":
+		return fieldShallow
+	case "":
+		return fieldCopy
+	default:
+		if name, ok := strings.CutPrefix(tag, "rename="); ok && name != "" {
+			return fieldCopy
+		}
+
+		return fieldInvalid
+	}
+}
+
+// hasReferences reports whether values of the given type can hold references
+// (pointers, maps, slices, interfaces, channels, functions or unsafe
+// pointers), directly or through nested arrays and struct fields.
+func hasReferences(t reflect.Type) bool {
+	return infoFor(t).references
+}
+
+// holdsReferences computes what hasReferences reports.
+func holdsReferences(t reflect.Type) bool {
+	var refs bool
+	switch t.Kind() {
+	case reflect.Array:
+		refs = hasReferences(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasReferences(t.Field(i).Type) {
+				refs = true
+				break
+			}
+		}
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map,
+		reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		refs = true
+	}
+
+	return refs
+}
+
+// isTriviallyCopyable reports whether values of the given type are plain
+// values whose copy by assignment is already a deep copy the default copy
+// logic would produce: scalars, and arrays and structs made only of them, with
+// no unexported fields, struct tags or custom copy logic at any level.
+func isTriviallyCopyable(t reflect.Type) bool {
+	return infoFor(t).trivial
+}
+
+// triviallyCopyable computes isTriviallyCopyable for a type without custom copy
+// logic, from the parts of its typeInfo computed so far.
+func triviallyCopyable(t reflect.Type, info *typeInfo) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return isTriviallyCopyable(t.Elem())
+	case reflect.Struct:
+		for _, field := range info.fields {
+			if !field.exported || field.directive != fieldCopy ||
+				!isTriviallyCopyable(t.Field(field.index).Type) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+		return true
+	default:
+		return false
+	}
+}
+
+// isImmutable reports whether values of the given type can not reference any
+// other memory, so sharing them can not be told apart from copying them:
+// scalars, and arrays and structs made only of them, with no unexported fields,
+// struct tags or custom copy logic at any level. Synchronization primitives,
+// atomic and linked container types are copied with their own semantics, so
+// they are never immutable either.
+func isImmutable(t reflect.Type) bool {
+	return infoFor(t).immutable
+}
+
+// immutable computes isImmutable for a type without custom copy logic, from
+// the parts of its typeInfo computed so far.
+func immutable(t reflect.Type, info *typeInfo) bool {
+	if _, ok := resetTypes[t]; ok || info.references || isAtomicType(t) ||
+		t == listType || t == ringType {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Array:
+		return isImmutable(t.Elem())
+	case reflect.Struct:
+		for _, field := range info.fields {
+			if !field.exported || field.directive != fieldCopy ||
+				!isImmutable(t.Field(field.index).Type) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasCustomCopy reports whether values of the given type may have a Copier
+// variant or a registered copy function used to copy them. Copier variants
+// implemented with a pointer receiver count too, as they are used for values.
+func hasCustomCopy(t reflect.Type) bool {
+	return infoFor(t).customCopy
+}
+
+// isBulkCopyable reports whether values of the given type can be copied with a
+// plain assignment, without going through recursiveCopy. This is the case for
+// scalar kinds unless the type has custom copy logic.
+func isBulkCopyable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+	default:
+		return false
+	}
+
+	// Any of the Copier variants, or a registered copy function.
+	return !hasCustomCopy(t)
+}
+
+// canBulkCopy reports whether slices with elements of type t can be copied all
+// at once, which is not the case if the options require seeing every element.
+func (cfg *config) canBulkCopy(t reflect.Type) bool {
+	if cfg.transform != nil {
+		return false
+	}
+
+	if cfg.errorOnUintptr && t.Kind() == reflect.Uintptr {
+		return false
+	}
+	if (cfg.internStrings || cfg.size != nil) && t.Kind() == reflect.String {
+		return false
+	}
+	if _, ok := cfg.binaryRoundTripTypes[t]; ok {
+		return false
+	}
+
+	return isBulkCopyable(t)
+}
+
+// canShareTrivial reports whether trivially copyable values can be used as
+// their own copy, which is not the case if the options require seeing every
+// nested value.
+func (cfg *config) canShareTrivial() bool {
+	return cfg.transform == nil && cfg.skipField == nil &&
+		!cfg.errorOnUintptr && !cfg.strictResources && !cfg.internStrings &&
+		cfg.maxDepth <= 0 && cfg.maxNodes <= 0 && cfg.stats == nil &&
+		cfg.size == nil && len(cfg.binaryRoundTripTypes) == 0
+}
@@ -0,0 +1,356 @@
+package deep
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStructFields(t *testing.T) {
+	type S struct {
+		A int
+		b int
+		C int `deep:"-"`
+		D int `deep:"shallow"`
+		E int `deep:"shalow"`
+		F int `deep:"rename=G"`
+	}
+
+	fields := structFields(reflect.TypeOf(S{}))
+
+	expected := []fieldInfo{
+		{index: 0, name: "A", exported: true, directive: fieldCopy},
+		{index: 1, name: "b", exported: false, directive: fieldCopy},
+		{index: 2, name: "C", exported: true, directive: fieldSkip, tag: "-"},
+		{index: 3, name: "D", exported: true, directive: fieldShallow, tag: "shallow"},
+		{index: 4, name: "E", exported: true, directive: fieldInvalid, tag: "shalow"},
+		{index: 5, name: "F", exported: true, directive: fieldCopy, tag: "rename=G", rename: "G"},
+	}
+
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, fields)
+	}
+}
+
+func TestStructFields_Cached(t *testing.T) {
+	type S struct {
+		A int
+	}
+
+	typ := reflect.TypeOf(S{})
+
+	first := structFields(typ)
+	second := structFields(typ)
+
+	if &first[0] != &second[0] {
+		t.Errorf("Expected cached field metadata to be reused")
+	}
+}
+
+func TestStructFields_Concurrent(t *testing.T) {
+	type S struct {
+		A int
+		B string
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := Copy(S{A: 42, B: "42"}); err != nil {
+				t.Errorf("Copy failed: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func BenchmarkCopy_SmallStructSlice(b *testing.B) {
+	type S struct {
+		A int
+		B string
+		C float64
+	}
+
+	src := make([]S, 100000)
+	for i := range src {
+		src[i] = S{A: i, B: "value", C: float64(i)}
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+type bulkCopier int
+
+func (b bulkCopier) DeepCopy() interface{} {
+	return b + 1
+}
+
+func TestIsBulkCopyable(t *testing.T) {
+	tests := []struct {
+		typ      reflect.Type
+		expected bool
+	}{
+		{reflect.TypeFor[int](), true},
+		{reflect.TypeFor[byte](), true},
+		{reflect.TypeFor[float64](), true},
+		{reflect.TypeFor[string](), true},
+		{reflect.TypeFor[complex64](), true},
+		{reflect.TypeFor[complex128](), true},
+		{reflect.TypeFor[*int](), false},
+		{reflect.TypeFor[struct{ A int }](), false},
+		{reflect.TypeFor[bulkCopier](), false},
+	}
+
+	for _, test := range tests {
+		if got := isBulkCopyable(test.typ); got != test.expected {
+			t.Errorf("Expected isBulkCopyable(%s) to be %v, got %v",
+				test.typ, test.expected, got)
+		}
+	}
+}
+
+func TestCopy_Slice_BulkCopy(t *testing.T) {
+	src := make([]byte, 1<<20)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Fatalf("Copy differs from source")
+	}
+
+	dst[0] = 255
+	if src[0] != 0 {
+		t.Errorf("Expected copy to be independent from source")
+	}
+}
+
+func TestCopy_Slice_BulkCopy_Copier(t *testing.T) {
+	dst, err := Copy([]bulkCopier{1, 2})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	// The custom copier must still run for every element.
+	if dst[0] != 2 || dst[1] != 3 {
+		t.Errorf("Expected [2 3], got %v", dst)
+	}
+}
+
+func TestCopy_Slice_BulkCopy_Complex(t *testing.T) {
+	src := make([]complex128, 100_000)
+	for i := range src {
+		src[i] = complex(float64(i), -float64(i))
+	}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if len(dst) != len(src) {
+		t.Fatalf("Expected %d elements, got %d", len(src), len(dst))
+	}
+	for i := range src {
+		if dst[i] != src[i] {
+			t.Fatalf("Expected element %d to be %v, got %v", i, src[i], dst[i])
+		}
+	}
+}
+
+func BenchmarkCopy_ByteSlice(b *testing.B) {
+	src := make([]byte, 1<<20)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func BenchmarkCopy_Complex128Slice(b *testing.B) {
+	src := make([]complex128, 100_000)
+	for i := range src {
+		src[i] = complex(float64(i), -float64(i))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func TestIsTriviallyCopyable(t *testing.T) {
+	tests := []struct {
+		typ      reflect.Type
+		expected bool
+	}{
+		{reflect.TypeFor[scalarRoot](), true},
+		{reflect.TypeFor[[2]scalarLeaf](), true},
+		{reflect.TypeFor[struct{ A *int }](), false},
+		{reflect.TypeFor[struct{ a int }](), false},
+		{reflect.TypeFor[struct {
+			A int `deep:"-"`
+		}](), false},
+		{reflect.TypeFor[struct{ A bulkCopier }](), false},
+		{reflect.TypeFor[struct{ Mu sync.Mutex }](), false},
+	}
+
+	for _, test := range tests {
+		if got := isTriviallyCopyable(test.typ); got != test.expected {
+			t.Errorf("Expected isTriviallyCopyable(%s) to be %v, got %v",
+				test.typ, test.expected, got)
+		}
+	}
+}
+
+func TestCopy_Trivial(t *testing.T) {
+	src := scalarRoot{M1: scalarMid{Leaves: [4]scalarLeaf{{A: 1, D: "d"}}}, N: 2}
+
+	doCopyAndCheck(t, src, false)
+}
+
+func TestCopy_Trivial_Allocs(t *testing.T) {
+	src := scalarRoot{M1: scalarMid{L1: scalarLeaf{A: 1, D: "d"}}, N: 2}
+
+	// Only the configuration of the copy is allocated.
+	allocs := testing.AllocsPerRun(100, func() {
+		MustCopy(src)
+	})
+	if allocs > 1 {
+		t.Errorf("Expected at most 1 allocation, got %v", allocs)
+	}
+}
+
+type trivialRegistered struct {
+	A int
+}
+
+func TestCopy_Trivial_Registered(t *testing.T) {
+	type S struct {
+		R trivialRegistered
+	}
+
+	// Copy once so that the type is cached as trivially copyable.
+	if _, err := Copy(S{}); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	Register(func(r trivialRegistered) trivialRegistered {
+		return trivialRegistered{A: r.A + 1}
+	})
+	t.Cleanup(Unregister[trivialRegistered])
+
+	dst, err := Copy(S{R: trivialRegistered{A: 1}})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.R.A != 2 {
+		t.Errorf("Expected registered copy function to be used, got %d", dst.R.A)
+	}
+}
+
+func TestCopy_Trivial_MaxDepth(t *testing.T) {
+	// Trivially copyable values are still descended into to honor limits.
+	if _, err := Copy(scalarRoot{}, WithMaxDepth(2)); err == nil {
+		t.Errorf("Copy did not fail on too deep value")
+	}
+}
+
+type scalarLeaf struct {
+	A, B int
+	C    float64
+	D    string
+}
+
+type scalarMid struct {
+	L1, L2 scalarLeaf
+	Leaves [4]scalarLeaf
+}
+
+type scalarRoot struct {
+	M1, M2 scalarMid
+	N      int
+}
+
+func BenchmarkCopy_ScalarStruct(b *testing.B) {
+	src := scalarRoot{M1: scalarMid{L1: scalarLeaf{A: 1, D: "d"}}, N: 2}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func TestCopy_BulkArray(t *testing.T) {
+	var src [1024]byte
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	var stats Stats
+	dst, err := Copy(&src, WithStats(&stats))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if *dst != src {
+		t.Errorf("Expected copied array to be equal to source")
+	}
+	if stats.Nodes != 2+len(src) {
+		t.Errorf("Expected %d visited values, got %d", 2+len(src), stats.Nodes)
+	}
+
+	// Arrays of pointers still copy every element.
+	one, two := 1, 2
+	ptrs := [2]*int{&one, &two}
+	dstPtrs, err := Copy(ptrs, WithStats(&Stats{}))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dstPtrs[0] == ptrs[0] || *dstPtrs[0] != 1 || *dstPtrs[1] != 2 {
+		t.Errorf("Expected pointers in the array to be deep copied")
+	}
+
+	// Options that need to see every element still do.
+	_, err = Copy([2]uintptr{0, 1}, WithErrorOnUintptr())
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) || unsupported.Path != "[1]" {
+		t.Errorf("Expected UnsupportedTypeError at [1], got %v", err)
+	}
+}
+
+func BenchmarkCopy_ByteArray(b *testing.B) {
+	var src [1024]byte
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	var stats Stats
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src, WithStats(&stats))
+	}
+}
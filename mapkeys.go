@@ -0,0 +1,90 @@
+package deep
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+)
+
+// sortMapKeys sorts keys in increasing order if they have integer, floating
+// point or string kinds. Otherwise, keys are left in their original order.
+func sortMapKeys(keys []reflect.Value) {
+	if len(keys) < 2 {
+		return
+	}
+
+	switch keys[0].Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return cmp.Compare(a.Int(), b.Int())
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return cmp.Compare(a.Uint(), b.Uint())
+		})
+	case reflect.Float32, reflect.Float64:
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return cmp.Compare(a.Float(), b.Float())
+		})
+	case reflect.String:
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return cmp.Compare(a.String(), b.String())
+		})
+	}
+}
+
+// CopyKey creates a deep copy of the map key k, in the same way the keys of
+// copied maps are copied. Keys can hold pointers, directly or inside arrays,
+// structs and interfaces, which then point to copies of their targets, so the
+// copy is only equal to k (under ==) if it holds no pointers. It returns the
+// copy and a nil error in case of success and the zero value for the type and
+// a non-nil error on failure, which includes copies that are not comparable
+// and so could not be used as keys. The behavior of the copy can be adjusted
+// with the given options.
+func CopyKey[K comparable](k K, opts ...Option) (K, error) {
+	cfg := newConfig(opts)
+	defer cfg.releaseTemporaries()
+
+	var zero K
+	v := rootValue(k)
+	if v.Kind() == reflect.Interface && v.IsNil() {
+		return zero, nil
+	}
+
+	cfg.setRoot(v)
+
+	dst, err := copyMapKey(v, nil, make(pointersMap), cfg)
+	if err != nil {
+		cfg.writePartial(dst)
+		return zero, err
+	}
+
+	if !dst.IsValid() {
+		return zero, nil
+	}
+
+	// A nil interface makes the assertion fail, which gives the zero value
+	// for K as well.
+	key, _ := dst.Interface().(K)
+
+	return key, nil
+}
+
+// copyMapKey deep copies the map key v, whose type has the typeInfo info (or
+// nil to look it up). Custom copy logic for interface keys
+// could return values that can not be compared, which would make using them as
+// keys panic, so that is reported as an error.
+func copyMapKey(v reflect.Value, info *typeInfo, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	dst, err := recursiveCopyWithInfo(v, info, pointers, cfg)
+	if err != nil || !dst.IsValid() {
+		return dst, err
+	}
+
+	if !dst.Comparable() {
+		return reflect.Value{}, cfg.incompatible(v.Type(), dst)
+	}
+
+	return dst, nil
+}
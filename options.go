@@ -0,0 +1,727 @@
+package deep
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Option configures the behavior of a copy. Options are applied in order, so
+// later options override earlier ones when they configure the same behavior.
+type Option func(*config)
+
+// config holds the settings used during a single copy operation.
+type config struct {
+	skipUnsupported             bool
+	maxDepth                    int
+	maxNodes                    int
+	newChannels                 bool
+	resetChannels               bool
+	shareFuncs                  bool
+	parallel                    int
+	reusePool                   bool
+	copyFullCapacity            bool
+	strictResources             bool
+	errorOnUintptr              bool
+	errorOnUnexported           bool
+	sortedMapKeys               bool
+	internStrings               bool
+	sharePointersAcrossElements bool
+	interiorPointers            bool
+	sharedBackingArrays         bool
+	shareImmutable              bool
+	ignoreBadTags               bool
+	shareClosers                bool
+	timeInUTC                   bool
+	dryRun                      bool
+	shallowTypes                map[reflect.Type]struct{}
+	binaryRoundTripTypes        map[reflect.Type]struct{}
+	sharedPointerTypes          map[reflect.Type]struct{}
+	unexportedFieldsFor         map[string]struct{}
+	fieldDefaults               map[string]reflect.Value
+	skipReport                  *[]SkippedField
+	stats                       *Stats
+	size                        *int64
+	transform                   func(path string, v reflect.Value) (reflect.Value, bool)
+	interfaceFactory            func(reflect.Type) (reflect.Value, bool)
+	skipField                   func(path string, sf reflect.StructField) bool
+	errorHandler                func(path string, t reflect.Type, err error) Decision
+	tracer                      func(event TraceEvent)
+	finalizer                   func(dst reflect.Value) error
+	partial                     reflect.Value
+	reuseSlice                  reflect.Value
+
+	// ctx, if not nil, is checked for cancellation during the copy.
+	ctx context.Context
+
+	// depth is the current nesting level while the copy is in progress.
+	depth int
+	// rootDepth is the depth the root value is copied at, which is 1 when it
+	// is held by an interface (e.g. for Copy[any]) and 0 otherwise.
+	rootDepth int
+	// visited is the number of values visited so far.
+	visited int
+	// trackPath is set if path has to be tracked for the options.
+	trackPath bool
+	// path is the path from the root value to the one being copied.
+	path []pathSegment
+	// temporaries are the pooled values obtained during the copy.
+	temporaries []temporary
+	// interned are the strings seen so far with WithStringInterning.
+	interned map[string]reflect.Value
+}
+
+var (
+	// defaultOptionsMu guards defaultOptions.
+	defaultOptionsMu sync.RWMutex
+	// defaultOptions are the options set with SetDefaultOptions.
+	defaultOptions []Option
+	// defaultConfig is the config with only defaultOptions applied, which
+	// copies given no options start from. It is never modified once stored.
+	defaultConfig atomic.Pointer[config]
+)
+
+// SetDefaultOptions makes the given options be applied to every copy before
+// the options given for the copy itself, so those can override them. This
+// allows establishing a policy for the whole application once, e.g. at
+// startup. Each call replaces the defaults set before, and calling it without
+// options removes them. Engines apply the defaults set when they are created.
+// It is safe to call concurrently with copies, which use the defaults set when
+// they start.
+//
+// Options taking a single value (e.g. WithMaxDepth) are overridden by giving
+// them again with another value, while the ones taking lists of types or
+// packages (e.g. WithShallowTypes) add to the defaults. The options without
+// arguments (e.g. WithSortedMapKeys or WithSkipUnsupported) are turned off for
+// a single copy with their Without form (e.g. WithoutSortedMapKeys).
+//
+// WithStats, WithSkipReport, WithPartialOnError and WithInPlaceSliceReuse can
+// not be defaults, as every copy, including concurrent ones, would then write
+// to the same target. SetDefaultOptions panics if it is given any of them.
+func SetDefaultOptions(opts ...Option) {
+	defaults := applyOptions(nil, opts)
+	if defaults.stats != nil || defaults.skipReport != nil ||
+		defaults.partial.IsValid() || defaults.reuseSlice.IsValid() {
+		panic("deep: options writing to a target can not be default options")
+	}
+
+	defaultOptionsMu.Lock()
+	defer defaultOptionsMu.Unlock()
+
+	defaultOptions = slices.Clone(opts)
+	defaultConfig.Store(defaults)
+}
+
+// newConfig returns the config for a copy done with the default options and
+// then opts.
+func newConfig(opts []Option) *config {
+	if len(opts) == 0 {
+		// The options adding to lists would modify the lists of the
+		// template, so it is only used as is.
+		cfg := &config{}
+		if defaults := defaultConfig.Load(); defaults != nil {
+			*cfg = *defaults
+		}
+
+		return cfg
+	}
+
+	defaultOptionsMu.RLock()
+	defaults := defaultOptions
+	defaultOptionsMu.RUnlock()
+
+	return applyOptions(defaults, opts)
+}
+
+// applyOptions returns a new config with defaults and then opts applied.
+func applyOptions(defaults, opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range defaults {
+		opt(cfg)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.trackPath = cfg.tracksPath()
+
+	return cfg
+}
+
+// WithSkipUnsupported makes unsupported types be skipped (the copy will have
+// the zero value for the type) instead of returning an error.
+func WithSkipUnsupported() Option {
+	return func(cfg *config) {
+		cfg.skipUnsupported = true
+	}
+}
+
+// WithoutSkipUnsupported turns off WithSkipUnsupported.
+func WithoutSkipUnsupported() Option {
+	return func(cfg *config) {
+		cfg.skipUnsupported = false
+	}
+}
+
+// SkippedField describes a value that was skipped during a copy, so the copy
+// has the zero value for its type instead.
+type SkippedField struct {
+	// Path is the location of the value relative to the root value being
+	// copied, in the same format as the Path of UnsupportedTypeError.
+	Path string
+	// Type is the type of the skipped value.
+	Type reflect.Type
+}
+
+// WithSkipReport makes every value skipped because of WithSkipUnsupported or
+// WithErrorHandler be appended to report, in the order they are found. This
+// allows auditing what was lost in the copy. It has no effect without one of
+// them.
+func WithSkipReport(report *[]SkippedField) Option {
+	return func(cfg *config) {
+		cfg.skipReport = report
+	}
+}
+
+// WithFieldDefault makes the value skipped at path (in the same format as the
+// Path of UnsupportedTypeError) be replaced by value in the copy, instead of
+// by the zero value for its type. This allows re-initializing values that can
+// not be copied, like channels or functions. value is used as it is, so it is
+// shared by all the copies made with the option, and it must be assignable to
+// the type of the skipped value. Like WithSkipReport, it has no effect without
+// WithSkipUnsupported or WithErrorHandler.
+func WithFieldDefault(path string, value interface{}) Option {
+	return func(cfg *config) {
+		if cfg.fieldDefaults == nil {
+			cfg.fieldDefaults = make(map[string]reflect.Value)
+		}
+
+		cfg.fieldDefaults[path] = reflect.ValueOf(value)
+	}
+}
+
+// Decision is what a WithErrorHandler handler decides to do with a value that
+// can not be copied.
+type Decision int
+
+const (
+	// Fail makes the copy fail with the error.
+	Fail Decision = iota
+	// Skip leaves the value with the zero value for its type in the copy.
+	Skip
+	// Share uses the value itself in the copy, shared with the source.
+	Share
+)
+
+// WithErrorHandler makes fn decide what to do with each value that can not be
+// copied, instead of always failing (or skipping it with WithSkipUnsupported,
+// which fn takes precedence over). fn is called with the path to the value (in
+// the same format as the Path of UnsupportedTypeError), its type and the error
+// the copy would fail with. This covers unsupported values, values over the
+// WithMaxDepth limit, resources found with WithStrictResources, invalid slices
+// and errors returned by CopierErr implementations. Errors that abort the
+// whole copy, like cancellation and WithMaxNodes, and the ones from
+// WithErrorOnUnexported always fail. With WithParallel, fn may be called
+// concurrently.
+func WithErrorHandler(fn func(path string, t reflect.Type, err error) Decision) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithMaxDepth limits how deeply nested the copied value can be. Each
+// descent into a non-nil pointer, interface, map or slice, and into any array
+// or struct, counts as one level. Once more than n levels are needed, the copy
+// fails (or, with WithSkipUnsupported, the value is replaced by the zero value
+// for its type). A value of n <= 0 means no limit, which is the default.
+func WithMaxDepth(n int) Option {
+	return func(cfg *config) {
+		cfg.maxDepth = n
+	}
+}
+
+// WithMaxNodes limits the number of values visited while copying, including
+// the root value and every nested value (pointers, struct fields, slice and
+// map elements, etc.). Once more than n values would be visited, the copy fails
+// with a *MaxNodesError and all partial work is discarded, even with
+// WithSkipUnsupported. This protects against copies that would amplify a small
+// input into a huge amount of memory. Setting a limit disables WithParallel. A
+// value of n <= 0 means no limit, which is the default.
+func WithMaxNodes(n int) Option {
+	return func(cfg *config) {
+		cfg.maxNodes = n
+	}
+}
+
+// WithStats makes statistics about the copy be accumulated into s. This helps
+// diagnosing why a particular copy is slow or allocation heavy.
+func WithStats(s *Stats) Option {
+	return func(cfg *config) {
+		cfg.stats = s
+	}
+}
+
+// WithTransform makes fn be called for every value visited during the copy,
+// with the path to the value (in the same format as the Path of
+// UnsupportedTypeError) and the source value. If fn returns true, the value it
+// returns is used in the copy instead of a copy of the source value, and the
+// source value is not descended into. The returned value must be assignable to
+// the type of the source value, or the copy fails with an
+// *IncompatibleValueError, and an invalid value stands for the zero value.
+// This allows redacting or normalizing values while copying. With
+// WithParallel, fn may be called concurrently.
+func WithTransform(fn func(path string, v reflect.Value) (reflect.Value, bool)) Option {
+	return func(cfg *config) {
+		cfg.transform = fn
+	}
+}
+
+// WithFinalize makes fn be called once with the copy of the root value, after
+// it is fully copied and before it is returned. The value fn is called with
+// can be set, so it can be used to fix up the copy, e.g. to recompute a cached
+// field. If fn returns an error, the copy fails with that error. Unlike
+// WithTransform, fn is not called for the nested values, nor when the copy
+// of the root value is the nil interface.
+func WithFinalize(fn func(dst reflect.Value) error) Option {
+	return func(cfg *config) {
+		cfg.finalizer = fn
+	}
+}
+
+// WithPartialOnError makes the copy built so far be written to dst when the
+// copy fails, to help finding out how far it got. Values that were not copied
+// yet are left with the zero value for their type. Nothing is written if the
+// copy succeeds or if the copied value is not assignable to T.
+func WithPartialOnError[T any](dst *T) Option {
+	return func(cfg *config) {
+		if dst != nil {
+			cfg.partial = reflect.ValueOf(dst).Elem()
+		}
+	}
+}
+
+// WithSkipField makes struct fields for which pred returns true be left with
+// the zero value for their type in the copy, just like fields tagged with
+// `deep:"-"`. pred is called with the path to the field (in the same format as
+// the Path of UnsupportedTypeError) and its description. This allows excluding
+// fields of types that can not be changed to have tags.
+func WithSkipField(pred func(path string, sf reflect.StructField) bool) Option {
+	return func(cfg *config) {
+		cfg.skipField = pred
+	}
+}
+
+// WithInterfaceFactory makes fn be called with the dynamic type of every
+// non-nil interface value found during the copy. If fn returns true, the value
+// it returns is used in the copy instead of a copy of the dynamic value, which
+// allows swapping implementations (e.g. replacing a logger with a no-op one).
+// The returned value must implement the interface, or the copy fails with an
+// *IncompatibleValueError, and an invalid value stands for a nil interface.
+func WithInterfaceFactory(fn func(reflect.Type) (reflect.Value, bool)) Option {
+	return func(cfg *config) {
+		cfg.interfaceFactory = fn
+	}
+}
+
+// WithNewChannels makes non-nil channels be copied as new, empty channels with
+// the same type and capacity instead of being unsupported. Values buffered in
+// the source channel are not copied. Unlike with WithResetChannels, the copied
+// channels can be used right away. It overrides an earlier WithResetChannels.
+func WithNewChannels() Option {
+	return func(cfg *config) {
+		cfg.newChannels = true
+		cfg.resetChannels = false
+	}
+}
+
+// WithoutNewChannels turns off WithNewChannels.
+func WithoutNewChannels() Option {
+	return func(cfg *config) {
+		cfg.newChannels = false
+	}
+}
+
+// WithResetChannels makes non-nil channels be copied as nil channels instead
+// of being unsupported. This is what WithSkipUnsupported does for channels too,
+// but it does not depend on it, nor affect other unsupported values, and the
+// channels are not reported as skipped. Unlike with WithNewChannels, no
+// channel is allocated, so the copy can not be used to communicate until its
+// channels are set. It overrides an earlier WithNewChannels.
+func WithResetChannels() Option {
+	return func(cfg *config) {
+		cfg.resetChannels = true
+		cfg.newChannels = false
+	}
+}
+
+// WithoutResetChannels turns off WithResetChannels.
+func WithoutResetChannels() Option {
+	return func(cfg *config) {
+		cfg.resetChannels = false
+	}
+}
+
+// WithShareFuncs makes non-nil functions be shared between the source and the
+// copy instead of being unsupported. Variables captured by closures are shared
+// as well.
+func WithShareFuncs() Option {
+	return func(cfg *config) {
+		cfg.shareFuncs = true
+	}
+}
+
+// WithoutShareFuncs turns off WithShareFuncs.
+func WithoutShareFuncs() Option {
+	return func(cfg *config) {
+		cfg.shareFuncs = false
+	}
+}
+
+// WithParallel makes the elements of a top-level slice be copied concurrently
+// by up to the given number of workers. As each worker has its own pointer
+// tracking, this only engages when the element type can not hold references
+// (pointers, maps, slices, interfaces, channels or functions) that could be
+// shared between elements. Otherwise, and for anything other than the
+// top-level slice, the copy is done serially.
+func WithParallel(workers int) Option {
+	return func(cfg *config) {
+		cfg.parallel = workers
+	}
+}
+
+// WithReusePool makes the temporary values used while copying structs and
+// arrays be taken from (and returned to) per-type pools, reducing allocations
+// when the same types are copied repeatedly. Pooled values are reset when they
+// are returned, so the pools do not keep copied data alive. This pays off for
+// large structs and arrays (see BenchmarkCopy_LargeStruct_ReusePool), while for
+// small ones going through the pools costs about as much as allocating them.
+func WithReusePool() Option {
+	return func(cfg *config) {
+		cfg.reusePool = true
+	}
+}
+
+// WithoutReusePool turns off WithReusePool.
+func WithoutReusePool() Option {
+	return func(cfg *config) {
+		cfg.reusePool = false
+	}
+}
+
+// WithCopyFullCapacity makes slices have all the elements up to their capacity
+// copied, instead of only the ones up to their length. This preserves data
+// stored beyond the length of pre-grown buffers.
+func WithCopyFullCapacity() Option {
+	return func(cfg *config) {
+		cfg.copyFullCapacity = true
+	}
+}
+
+// WithoutCopyFullCapacity turns off WithCopyFullCapacity.
+func WithoutCopyFullCapacity() Option {
+	return func(cfg *config) {
+		cfg.copyFullCapacity = false
+	}
+}
+
+// WithInPlaceSliceReuse makes the copy of a top-level []T be made into
+// existing when its capacity is enough for the copied elements, instead of
+// into a newly allocated slice. Otherwise, a new slice is allocated as usual.
+// This reduces the garbage produced when the same large slice is copied
+// repeatedly, passing the previous copy as existing. The elements of existing
+// are overwritten, so it must not be used by the source value. The copy fails
+// with an *IncompatibleValueError if the top-level slice does not have
+// elements of type T. It has no effect on other values, nor on CopyEach.
+func WithInPlaceSliceReuse[T any](existing []T) Option {
+	return func(cfg *config) {
+		cfg.reuseSlice = reflect.ValueOf(existing)
+	}
+}
+
+// WithStrictResources makes the copy fail with a *ResourceError when a value
+// holding a resource that can not be meaningfully copied is found, even if it
+// would otherwise be copied, shared or skipped. Resources are non-nil
+// functions and channels, non-nil *os.File pointers, locked sync.Mutex and
+// sync.RWMutex values and non-zero values of the types registered with
+// RegisterResource.
+func WithStrictResources() Option {
+	return func(cfg *config) {
+		cfg.strictResources = true
+	}
+}
+
+// WithoutStrictResources turns off WithStrictResources.
+func WithoutStrictResources() Option {
+	return func(cfg *config) {
+		cfg.strictResources = false
+	}
+}
+
+// WithErrorOnUintptr makes non-zero uintptr values be unsupported, so the copy
+// fails with an *UnsupportedTypeError (or, with WithSkipUnsupported, the value
+// is replaced by zero). By default they are copied as plain integers, which is
+// dangerous if they actually hold pointers that are later reinterpreted.
+func WithErrorOnUintptr() Option {
+	return func(cfg *config) {
+		cfg.errorOnUintptr = true
+	}
+}
+
+// WithoutErrorOnUintptr turns off WithErrorOnUintptr.
+func WithoutErrorOnUintptr() Option {
+	return func(cfg *config) {
+		cfg.errorOnUintptr = false
+	}
+}
+
+// WithErrorOnUnexported makes the copy fail with an *UnexportedFieldError when
+// a struct with an unexported field is found, instead of silently leaving the
+// field with the zero value for its type in the copy. Fields copied because of
+// WithUnexportedFieldsFor and blank (_) fields are not reported.
+func WithErrorOnUnexported() Option {
+	return func(cfg *config) {
+		cfg.errorOnUnexported = true
+	}
+}
+
+// WithoutErrorOnUnexported turns off WithErrorOnUnexported.
+func WithoutErrorOnUnexported() Option {
+	return func(cfg *config) {
+		cfg.errorOnUnexported = false
+	}
+}
+
+// WithTimeInUTC makes copies of time.Time values be in UTC, representing the
+// same instant as the source. By default, copies keep the location of the
+// source.
+func WithTimeInUTC() Option {
+	return func(cfg *config) {
+		cfg.timeInUTC = true
+	}
+}
+
+// WithoutTimeInUTC turns off WithTimeInUTC.
+func WithoutTimeInUTC() Option {
+	return func(cfg *config) {
+		cfg.timeInUTC = false
+	}
+}
+
+// WithShareClosers makes interface values holding a value that implements
+// io.Closer (like net.Conn or io.ReadCloser values) be shared with the source
+// instead of deep copied. These usually wrap live resources like connections
+// and files, which a copy of their internals would corrupt.
+func WithShareClosers() Option {
+	return func(cfg *config) {
+		cfg.shareClosers = true
+	}
+}
+
+// WithoutShareClosers turns off WithShareClosers.
+func WithoutShareClosers() Option {
+	return func(cfg *config) {
+		cfg.shareClosers = false
+	}
+}
+
+// WithIgnoreBadTags makes struct fields with a `deep` tag holding an
+// unrecognized directive be copied as if they had no tag, instead of making
+// the copy fail with an *InvalidTagError.
+func WithIgnoreBadTags() Option {
+	return func(cfg *config) {
+		cfg.ignoreBadTags = true
+	}
+}
+
+// WithoutIgnoreBadTags turns off WithIgnoreBadTags.
+func WithoutIgnoreBadTags() Option {
+	return func(cfg *config) {
+		cfg.ignoreBadTags = false
+	}
+}
+
+// WithSortedMapKeys makes map entries be copied in the order of their keys
+// for keys with integer, floating point or string kinds, and in an arbitrary
+// order otherwise. This does not change the copy, but makes its traversal
+// deterministic, as seen by WithTransform or WithSkipReport.
+func WithSortedMapKeys() Option {
+	return func(cfg *config) {
+		cfg.sortedMapKeys = true
+	}
+}
+
+// WithoutSortedMapKeys turns off WithSortedMapKeys.
+func WithoutSortedMapKeys() Option {
+	return func(cfg *config) {
+		cfg.sortedMapKeys = false
+	}
+}
+
+// WithShareImmutable makes arrays and structs that can not reference any other
+// memory (no pointers, maps, slices, interfaces, channels, functions or unsafe
+// pointers at any level) be used as their own copy, instead of being copied
+// field by field. This saves work for big nested values like configuration
+// trees, even with options that would otherwise need to see every nested value
+// (e.g. WithStats or WithMaxDepth), so the values nested in them are not seen
+// by WithTransform, WithSkipField and similar options. Values with unexported
+// fields, fields tagged with `deep` or custom copy logic at any level are still
+// copied as usual, and so are synchronization primitives and atomic types.
+func WithShareImmutable() Option {
+	return func(cfg *config) {
+		cfg.shareImmutable = true
+	}
+}
+
+// WithoutShareImmutable turns off WithShareImmutable.
+func WithoutShareImmutable() Option {
+	return func(cfg *config) {
+		cfg.shareImmutable = false
+	}
+}
+
+// WithSharedBackingArrays makes slices sharing the same backing array share the
+// same copied backing array, with the same offsets, instead of each getting its
+// own. For example, a copy of a struct holding both a and a[2:5] keeps the
+// second slice pointing into the first one. This only works for slices found
+// after a slice starting at or before them in the array (e.g. in a later struct
+// field), and whose capacities end at the same place, which is the case unless
+// a full slice expression limited the capacity. The copies of those slices
+// hold all the elements up to their capacity, as with WithCopyFullCapacity.
+func WithSharedBackingArrays() Option {
+	return func(cfg *config) {
+		cfg.sharedBackingArrays = true
+	}
+}
+
+// WithoutSharedBackingArrays turns off WithSharedBackingArrays.
+func WithoutSharedBackingArrays() Option {
+	return func(cfg *config) {
+		cfg.sharedBackingArrays = false
+	}
+}
+
+// WithInteriorPointers makes pointers to slice elements point to the matching
+// elements of the copied slice, instead of to separate copies of the elements.
+// This only works for pointers found after the slice during the copy (e.g. in
+// a later struct field), as pointers found before already have their own
+// copy. Pointers to struct fields and array elements are never preserved this
+// way. Recording the element addresses makes copying slices slower, so this is
+// disabled by default.
+func WithInteriorPointers() Option {
+	return func(cfg *config) {
+		cfg.interiorPointers = true
+	}
+}
+
+// WithoutInteriorPointers turns off WithInteriorPointers.
+func WithoutInteriorPointers() Option {
+	return func(cfg *config) {
+		cfg.interiorPointers = false
+	}
+}
+
+// WithSharePointersAcrossElements makes CopyEach preserve pointer identity
+// across the copies of all elements, so if two elements reference the same
+// object, their copies also reference the same (copied) object. This retains a
+// reference to every copied object until CopyEach returns. Other functions are
+// not affected.
+func WithSharePointersAcrossElements() Option {
+	return func(cfg *config) {
+		cfg.sharePointersAcrossElements = true
+	}
+}
+
+// WithoutSharePointersAcrossElements turns off WithSharePointersAcrossElements.
+func WithoutSharePointersAcrossElements() Option {
+	return func(cfg *config) {
+		cfg.sharePointersAcrossElements = false
+	}
+}
+
+// WithStringInterning makes equal strings share the same memory in the copy,
+// reducing memory usage when many values hold identical strings that were
+// built separately. Strings are immutable, so this is safe.
+func WithStringInterning() Option {
+	return func(cfg *config) {
+		cfg.internStrings = true
+	}
+}
+
+// WithoutStringInterning turns off WithStringInterning.
+func WithoutStringInterning() Option {
+	return func(cfg *config) {
+		cfg.internStrings = false
+	}
+}
+
+// WithShallowTypes makes values of the given types be copied by direct
+// assignment instead of being deep copied during this copy, just like
+// RegisterShallow does globally.
+func WithShallowTypes(types ...reflect.Type) Option {
+	return func(cfg *config) {
+		if cfg.shallowTypes == nil {
+			cfg.shallowTypes = make(map[reflect.Type]struct{}, len(types))
+		}
+
+		for _, t := range types {
+			cfg.shallowTypes[t] = struct{}{}
+		}
+	}
+}
+
+// WithBinaryRoundTrip makes values of the given types be copied by marshaling
+// them with their MarshalBinary method and unmarshaling the result with the
+// UnmarshalBinary method of a new value, instead of being deep copied. This is
+// slower, but guarantees the copy is independent for opaque types with complex
+// internal state. MarshalBinary may have a pointer receiver, and
+// UnmarshalBinary must have one. Pointer types can be given too, which are
+// copied as new pointers to the unmarshaled values. The copy fails with a
+// *BinaryRoundTripError if either method is missing or fails.
+func WithBinaryRoundTrip(types ...reflect.Type) Option {
+	return func(cfg *config) {
+		if cfg.binaryRoundTripTypes == nil {
+			cfg.binaryRoundTripTypes = make(map[reflect.Type]struct{},
+				len(types))
+		}
+
+		for _, t := range types {
+			cfg.binaryRoundTripTypes[t] = struct{}{}
+		}
+	}
+}
+
+// WithUnexportedFieldsFor makes the unexported fields of struct types defined
+// in the packages with the given import paths be copied too, instead of being
+// left with the zero value for their type. This uses package unsafe to access
+// the fields, so it should only be used for packages whose types are known to
+// be safe to copy, such as the caller's own. For instantiations of generic
+// types, the package is the one defining the generic type, regardless of the
+// packages of its type arguments.
+func WithUnexportedFieldsFor(pkgPaths ...string) Option {
+	return func(cfg *config) {
+		if cfg.unexportedFieldsFor == nil {
+			cfg.unexportedFieldsFor = make(map[string]struct{}, len(pkgPaths))
+		}
+
+		for _, pkgPath := range pkgPaths {
+			cfg.unexportedFieldsFor[pkgPath] = struct{}{}
+		}
+	}
+}
+
+// WithSharedPointerTypes makes pointers to values of the given types keep
+// pointing to the same target in the copy instead of to a copy of the target.
+// This is useful for singletons, like a shared configuration. Note the types
+// are the types pointed to, not the pointer types.
+func WithSharedPointerTypes(types ...reflect.Type) Option {
+	return func(cfg *config) {
+		if cfg.sharedPointerTypes == nil {
+			cfg.sharedPointerTypes = make(map[reflect.Type]struct{}, len(types))
+		}
+
+		for _, t := range types {
+			cfg.sharedPointerTypes[t] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,1816 @@
+package deep
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestCopy_WithSkipUnsupported(t *testing.T) {
+	type S struct {
+		A int
+		B chan int
+	}
+
+	src := S{A: 42, B: make(chan int)}
+
+	if _, err := Copy(src); err == nil {
+		t.Errorf("Copy without options did not fail on unsupported field")
+	}
+
+	dst, err := Copy(src, WithSkipUnsupported())
+	if err != nil {
+		t.Fatalf("Copy with WithSkipUnsupported failed: %v", err)
+	}
+
+	if dst.A != src.A {
+		t.Errorf("Copy failed: expected %v, got %v", src.A, dst.A)
+	}
+
+	if dst.B != nil {
+		t.Errorf("Copy failed: expected nil channel, got non-nil")
+	}
+}
+
+func TestCopyInto_WithSkipUnsupported(t *testing.T) {
+	var dst func()
+	if err := CopyInto(&dst, func() {}, WithSkipUnsupported()); err != nil {
+		t.Fatalf("CopyInto with WithSkipUnsupported failed: %v", err)
+	}
+
+	if dst != nil {
+		t.Errorf("CopyInto failed: expected nil func, got non-nil")
+	}
+}
+
+func TestCopy_WithSkipReport(t *testing.T) {
+	type Inner struct {
+		Events chan string
+	}
+
+	type S struct {
+		A     int
+		Done  chan struct{}
+		Inner []Inner
+	}
+
+	src := S{A: 1, Done: make(chan struct{}),
+		Inner: []Inner{{}, {Events: make(chan string)}}}
+
+	var report []SkippedField
+	if _, err := Copy(src, WithSkipUnsupported(),
+		WithSkipReport(&report)); err != nil {
+		t.Fatalf("Copy with WithSkipReport failed: %v", err)
+	}
+
+	expected := []SkippedField{
+		{Path: "Done", Type: reflect.TypeFor[chan struct{}]()},
+		{Path: "Inner[1].Events", Type: reflect.TypeFor[chan string]()},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected report %v, got %v", expected, report)
+	}
+}
+
+func TestCopy_WithFieldDefault(t *testing.T) {
+	type Inner struct {
+		Events chan string
+	}
+
+	type S struct {
+		Done  chan struct{}
+		Inner []Inner
+	}
+
+	src := S{Done: make(chan struct{}),
+		Inner: []Inner{{Events: make(chan string)}, {Events: make(chan string)}}}
+
+	done := make(chan struct{})
+	events := make(chan string, 1)
+	dst, err := Copy(src, WithSkipUnsupported(),
+		WithFieldDefault("Done", done),
+		WithFieldDefault("Inner[1].Events", events))
+	if err != nil {
+		t.Fatalf("Copy with WithFieldDefault failed: %v", err)
+	}
+
+	if dst.Done != done {
+		t.Errorf("Expected the default channel for Done")
+	}
+	if dst.Inner[0].Events != nil {
+		t.Errorf("Expected nil channel without a default")
+	}
+	if dst.Inner[1].Events != events {
+		t.Errorf("Expected the default channel for Inner[1].Events")
+	}
+
+	// The default is only used for skipped values.
+	if _, err := Copy(src, WithFieldDefault("Done", done)); err == nil {
+		t.Errorf("Expected error without WithSkipUnsupported")
+	}
+}
+
+func TestCopy_WithFieldDefault_Incompatible(t *testing.T) {
+	type S struct {
+		Done chan struct{}
+	}
+
+	_, err := Copy(S{Done: make(chan struct{})}, WithSkipUnsupported(),
+		WithFieldDefault("Done", make(chan int)))
+
+	var incompatible *IncompatibleValueError
+	if !errors.As(err, &incompatible) || incompatible.Path != "Done" {
+		t.Errorf("Expected IncompatibleValueError at Done, got %v", err)
+	}
+
+	// nil stands for the zero value.
+	dst, err := Copy(S{Done: make(chan struct{})}, WithSkipUnsupported(),
+		WithFieldDefault("Done", nil))
+	if err != nil || dst.Done != nil {
+		t.Errorf("Expected nil channel for a nil default, got %v", err)
+	}
+}
+
+func TestNewConfig_OptionsCompose(t *testing.T) {
+	unsetSkip := func(cfg *config) {
+		cfg.skipUnsupported = false
+	}
+
+	if cfg := newConfig(nil); cfg.skipUnsupported {
+		t.Errorf("Expected skipUnsupported to be false by default")
+	}
+
+	if cfg := newConfig([]Option{WithSkipUnsupported()}); !cfg.skipUnsupported {
+		t.Errorf("Expected skipUnsupported to be true")
+	}
+
+	// Options are applied in order, so later ones win.
+	if cfg := newConfig([]Option{WithSkipUnsupported(), unsetSkip}); cfg.skipUnsupported {
+		t.Errorf("Expected later option to override earlier one")
+	}
+
+	if cfg := newConfig([]Option{unsetSkip, WithSkipUnsupported()}); !cfg.skipUnsupported {
+		t.Errorf("Expected later option to override earlier one")
+	}
+}
+
+func TestSetDefaultOptions(t *testing.T) {
+	type S struct {
+		A  int
+		Ch chan int
+	}
+
+	src := S{A: 1, Ch: make(chan int)}
+
+	SetDefaultOptions(WithSkipUnsupported())
+	t.Cleanup(func() { SetDefaultOptions() })
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy with default WithSkipUnsupported failed: %v", err)
+	}
+	if dst.A != 1 || dst.Ch != nil {
+		t.Errorf("Expected A to be copied and Ch to be skipped, got %+v", dst)
+	}
+
+	if dst := MustCopy(src); dst.Ch != nil {
+		t.Errorf("Expected MustCopy to skip Ch too")
+	}
+
+	// Options given for the copy override the defaults.
+	if _, err := Copy(src, WithoutSkipUnsupported()); err == nil {
+		t.Errorf("Expected per-call option to override the default")
+	}
+
+	dst, err = Copy(src, WithNewChannels())
+	if err != nil || dst.Ch == nil || dst.Ch == src.Ch {
+		t.Errorf("Expected a new channel with WithNewChannels, got %v", err)
+	}
+
+	SetDefaultOptions()
+	if _, err := Copy(src); err == nil {
+		t.Errorf("Expected error once the defaults are removed")
+	}
+}
+
+func TestSetDefaultOptions_Lists(t *testing.T) {
+	type A struct{ P *int }
+	type B struct{ P *int }
+
+	SetDefaultOptions(WithShallowTypes(reflect.TypeFor[A]()))
+	t.Cleanup(func() { SetDefaultOptions() })
+
+	n := 1
+	a, b := A{P: &n}, B{P: &n}
+
+	// Types given for a copy add to the defaults for that copy only.
+	type S struct {
+		A A
+		B B
+	}
+	dst := MustCopy(S{A: a, B: b}, WithShallowTypes(reflect.TypeFor[B]()))
+	if dst.A.P != &n || dst.B.P != &n {
+		t.Errorf("Expected both A and B to be shallow copied")
+	}
+
+	if dst := MustCopy(S{A: a, B: b}); dst.A.P != &n || dst.B.P == &n {
+		t.Errorf("Expected only A to be shallow copied by default")
+	}
+}
+
+func TestSetDefaultOptions_WritingOptions(t *testing.T) {
+	t.Cleanup(func() { SetDefaultOptions() })
+
+	var stats Stats
+	var report []SkippedField
+	var partial int
+	tests := map[string]Option{
+		"WithStats":             WithStats(&stats),
+		"WithSkipReport":        WithSkipReport(&report),
+		"WithPartialOnError":    WithPartialOnError(&partial),
+		"WithInPlaceSliceReuse": WithInPlaceSliceReuse(make([]int, 1)),
+	}
+
+	for name, opt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected SetDefaultOptions to panic with %s", name)
+				}
+			}()
+
+			SetDefaultOptions(WithSkipUnsupported(), opt)
+		}()
+	}
+
+	// The defaults are left as they were.
+	if _, err := Copy(make(chan int)); err == nil {
+		t.Errorf("Expected the rejected defaults to not be set")
+	}
+}
+
+func TestWithout(t *testing.T) {
+	tests := map[string][2]Option{
+		"SkipUnsupported":   {WithSkipUnsupported(), WithoutSkipUnsupported()},
+		"NewChannels":       {WithNewChannels(), WithoutNewChannels()},
+		"ResetChannels":     {WithResetChannels(), WithoutResetChannels()},
+		"ShareFuncs":        {WithShareFuncs(), WithoutShareFuncs()},
+		"ReusePool":         {WithReusePool(), WithoutReusePool()},
+		"CopyFullCapacity":  {WithCopyFullCapacity(), WithoutCopyFullCapacity()},
+		"StrictResources":   {WithStrictResources(), WithoutStrictResources()},
+		"ErrorOnUintptr":    {WithErrorOnUintptr(), WithoutErrorOnUintptr()},
+		"ErrorOnUnexported": {WithErrorOnUnexported(), WithoutErrorOnUnexported()},
+		"TimeInUTC":         {WithTimeInUTC(), WithoutTimeInUTC()},
+		"ShareClosers":      {WithShareClosers(), WithoutShareClosers()},
+		"IgnoreBadTags":     {WithIgnoreBadTags(), WithoutIgnoreBadTags()},
+		"SortedMapKeys":     {WithSortedMapKeys(), WithoutSortedMapKeys()},
+		"ShareImmutable":    {WithShareImmutable(), WithoutShareImmutable()},
+		"SharedBackingArrays": {WithSharedBackingArrays(),
+			WithoutSharedBackingArrays()},
+		"InteriorPointers": {WithInteriorPointers(), WithoutInteriorPointers()},
+		"SharePointersAcrossElements": {WithSharePointersAcrossElements(),
+			WithoutSharePointersAcrossElements()},
+		"StringInterning": {WithStringInterning(), WithoutStringInterning()},
+	}
+
+	for name, opts := range tests {
+		if got := newConfig(opts[:]); !reflect.DeepEqual(got, newConfig(nil)) {
+			t.Errorf("Expected Without%s to undo With%s", name, name)
+		}
+	}
+}
+
+type depthNode struct {
+	Value int
+	Next  *depthNode
+}
+
+func newDepthList(n int) *depthNode {
+	var head *depthNode
+	for i := n; i > 0; i-- {
+		head = &depthNode{Value: i, Next: head}
+	}
+
+	return head
+}
+
+func TestCopy_WithMaxDepth(t *testing.T) {
+	const length = 5
+
+	// Every node is a pointer plus a struct, so two levels per node.
+	src := newDepthList(length)
+
+	dst, err := Copy(src, WithMaxDepth(2*length))
+	if err != nil {
+		t.Fatalf("Copy failed at the depth limit: %v", err)
+	}
+
+	count := 0
+	for n := dst; n != nil; n = n.Next {
+		count++
+	}
+	if count != length {
+		t.Errorf("Expected %d nodes, got %d", length, count)
+	}
+
+	_, err = Copy(src, WithMaxDepth(2*length-1))
+	if err == nil {
+		t.Fatalf("Copy did not fail beyond the depth limit")
+	}
+
+	if !strings.Contains(err.Error(), "maximum depth of 9") ||
+		!strings.Contains(err.Error(), "depthNode") {
+		t.Errorf("Expected error to mention the depth and type, got: %v", err)
+	}
+}
+
+func TestCopy_WithMaxDepth_Unlimited(t *testing.T) {
+	src := newDepthList(1000)
+
+	if _, err := Copy(src, WithMaxDepth(0)); err != nil {
+		t.Errorf("Copy failed without a depth limit: %v", err)
+	}
+}
+
+func TestCopy_WithMaxDepth_SkipUnsupported(t *testing.T) {
+	src := newDepthList(3)
+
+	// Allows the first two nodes only.
+	dst, err := Copy(src, WithMaxDepth(4), WithSkipUnsupported())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Value != 1 || dst.Next.Value != 2 {
+		t.Errorf("Expected first two nodes to be copied")
+	}
+
+	if dst.Next.Next != nil {
+		t.Errorf("Expected nodes beyond the depth limit to be zeroed")
+	}
+}
+
+func TestCopy_WithNewChannels(t *testing.T) {
+	type S struct {
+		A chan int
+		B <-chan string
+		C chan int
+	}
+
+	a := make(chan int, 3)
+	a <- 42
+	b := make(chan string)
+	src := S{A: a, B: b, C: a}
+
+	dst, err := Copy(src, WithNewChannels())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.A == nil || dst.A == src.A {
+		t.Fatalf("Expected a new channel")
+	}
+
+	if cap(dst.A) != 3 || len(dst.A) != 0 {
+		t.Errorf("Expected an empty channel with capacity 3, got len %d and cap %d",
+			len(dst.A), cap(dst.A))
+	}
+
+	if dst.B == nil || dst.B == src.B {
+		t.Errorf("Expected a new receive-only channel")
+	}
+
+	if dst.C != dst.A {
+		t.Errorf("Expected the same source channel to become the same new channel")
+	}
+
+	if len(src.A) != 1 {
+		t.Errorf("Expected source channel to be left untouched")
+	}
+}
+
+func TestCopy_WithResetChannels(t *testing.T) {
+	type S struct {
+		Name   string
+		Values []int
+		Done   chan struct{}
+		Events <-chan string
+		Any    interface{}
+	}
+
+	src := S{Name: "s", Values: []int{1, 2}, Done: make(chan struct{}),
+		Events: make(chan string, 1), Any: make(chan int)}
+
+	var report []SkippedField
+	dst, err := Copy(src, WithResetChannels(), WithSkipReport(&report))
+	if err != nil {
+		t.Fatalf("Copy with WithResetChannels failed: %v", err)
+	}
+
+	if dst.Done != nil || dst.Events != nil {
+		t.Errorf("Expected nil channels")
+	}
+	if ch, ok := dst.Any.(chan int); !ok || ch != nil {
+		t.Errorf("Expected nil chan int in interface, got %v", dst.Any)
+	}
+
+	if dst.Name != "s" || !reflect.DeepEqual(dst.Values, src.Values) ||
+		&dst.Values[0] == &src.Values[0] {
+		t.Errorf("Expected the other fields to be copied")
+	}
+
+	if len(report) != 0 {
+		t.Errorf("Expected reset channels not to be reported, got %v", report)
+	}
+
+	// Other unsupported values still fail.
+	if _, err := Copy(func() {}, WithResetChannels()); err == nil {
+		t.Errorf("Expected error for func with WithResetChannels")
+	}
+}
+
+func TestCopy_WithResetChannels_NewChannels(t *testing.T) {
+	src := make(chan int)
+
+	dst, err := Copy(src, WithNewChannels(), WithResetChannels())
+	if err != nil || dst != nil {
+		t.Errorf("Expected later WithResetChannels to win, got %v, %v",
+			dst, err)
+	}
+
+	dst, err = Copy(src, WithResetChannels(), WithNewChannels())
+	if err != nil || dst == nil || dst == src {
+		t.Errorf("Expected later WithNewChannels to win, got %v, %v",
+			dst, err)
+	}
+}
+
+func TestCopy_WithShareFuncs(t *testing.T) {
+	type S struct {
+		Value    int
+		Callback func() int
+	}
+
+	captured := 41
+	src := S{Value: 1, Callback: func() int {
+		captured++
+		return captured
+	}}
+
+	if _, err := Copy(src); err == nil {
+		t.Errorf("Copy without WithShareFuncs did not fail on func field")
+	}
+
+	dst, err := Copy(src, WithShareFuncs())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Callback == nil {
+		t.Fatalf("Expected func to be shared, got nil")
+	}
+
+	if got := dst.Callback(); got != 42 {
+		t.Errorf("Expected copied callback to return 42, got %d", got)
+	}
+
+	// The captured variable is shared with the source closure.
+	if got := src.Callback(); got != 43 {
+		t.Errorf("Expected source callback to return 43, got %d", got)
+	}
+}
+
+func TestCopy_WithCopyFullCapacity(t *testing.T) {
+	a, b, c := 1, 2, 3
+	backing := []*int{&a, &b, &c}
+	src := backing[:1]
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if full := dst[:cap(dst)]; full[1] != nil || full[2] != nil {
+		t.Errorf("Expected capacity region to be zero by default")
+	}
+
+	dst, err = Copy(src, WithCopyFullCapacity())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if len(dst) != 1 || cap(dst) != 3 {
+		t.Fatalf("Expected len 1 and cap 3, got len %d and cap %d",
+			len(dst), cap(dst))
+	}
+
+	full := dst[:cap(dst)]
+	for i, p := range full {
+		if p == nil {
+			t.Fatalf("Expected element %d to be copied", i)
+		}
+		if p == backing[i] {
+			t.Errorf("Expected element %d to be independent from source", i)
+		}
+		if *p != *backing[i] {
+			t.Errorf("Expected element %d to be %d, got %d", i, *backing[i], *p)
+		}
+	}
+}
+
+func TestCopy_WithSharedPointerTypes(t *testing.T) {
+	type Config struct {
+		Name string
+	}
+
+	type Data struct {
+		Value int
+	}
+
+	type S struct {
+		Config *Config
+		Data   *Data
+	}
+
+	src := S{Config: &Config{Name: "config"}, Data: &Data{Value: 42}}
+
+	dst, err := Copy(src, WithSharedPointerTypes(reflect.TypeFor[Config]()))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Config != src.Config {
+		t.Errorf("Expected pointer to shared type to be preserved")
+	}
+
+	if dst.Data == src.Data {
+		t.Errorf("Expected other pointers to be deep copied")
+	}
+
+	if dst.Data.Value != 42 {
+		t.Errorf("Expected Data.Value to be 42, got %d", dst.Data.Value)
+	}
+}
+
+func TestCopy_WithMaxNodes(t *testing.T) {
+	type S struct {
+		A int
+		B []int
+		C map[string]int
+	}
+
+	// The pointer, S, A, B, 3 elements of B, C, and a key and a value for C.
+	src := &S{A: 1, B: []int{1, 2, 3}, C: map[string]int{"a": 1}}
+	const nodes = 1 + 1 + 1 + 1 + 3 + 1 + 2
+
+	dst, err := Copy(src, WithMaxNodes(nodes))
+	if err != nil {
+		t.Fatalf("Copy failed at the node limit: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Expected %v, got %v", src, dst)
+	}
+
+	dst, err = Copy(src, WithMaxNodes(nodes-1))
+
+	var maxNodes *MaxNodesError
+	if !errors.As(err, &maxNodes) {
+		t.Fatalf("Expected a MaxNodesError, got %v", err)
+	}
+
+	if maxNodes.MaxNodes != nodes-1 {
+		t.Errorf("Expected max nodes %d, got %d", nodes-1, maxNodes.MaxNodes)
+	}
+
+	// Partial work is discarded.
+	if dst != nil {
+		t.Errorf("Expected a nil result, got %v", dst)
+	}
+}
+
+func TestCopy_WithMaxNodes_SkipUnsupported(t *testing.T) {
+	src := newDepthList(100)
+
+	if _, err := Copy(src, WithMaxNodes(10), WithSkipUnsupported()); err == nil {
+		t.Errorf("Expected node limit to fail even when skipping unsupported types")
+	}
+}
+
+func TestCopy_WithMaxNodes_Parallel(t *testing.T) {
+	src := make([][2]int, 100)
+
+	if _, err := Copy(src, WithMaxNodes(100), WithParallel(4)); err == nil {
+		t.Errorf("Expected node limit to be enforced with WithParallel")
+	}
+}
+
+func TestCopy_WithStrictResources(t *testing.T) {
+	type S struct {
+		A     int
+		Ready chan struct{}
+	}
+
+	var resource *ResourceError
+
+	_, err := Copy(S{Ready: make(chan struct{})}, WithStrictResources(),
+		WithNewChannels())
+	if !errors.As(err, &resource) {
+		t.Fatalf("Expected a ResourceError for a channel, got %v", err)
+	}
+	if resource.Path != "Ready" {
+		t.Errorf("Expected path Ready, got %q", resource.Path)
+	}
+
+	// Nil channels do not hold any resource.
+	if _, err := Copy(S{A: 1}, WithStrictResources()); err != nil {
+		t.Errorf("Copy with WithStrictResources failed: %v", err)
+	}
+
+	_, err = Copy(os.Stdout, WithStrictResources())
+	if !errors.As(err, &resource) {
+		t.Fatalf("Expected a ResourceError for a file, got %v", err)
+	}
+	if resource.Type != reflect.TypeFor[*os.File]() {
+		t.Errorf("Expected type *os.File, got %v", resource.Type)
+	}
+}
+
+func TestCopy_WithStrictResources_LockedMutex(t *testing.T) {
+	type S struct {
+		mu sync.Mutex
+		Mu sync.Mutex
+	}
+
+	src := &S{}
+	if _, err := Copy(src, WithStrictResources()); err != nil {
+		t.Fatalf("Copy with unlocked mutex failed: %v", err)
+	}
+
+	src.Mu.Lock()
+	defer src.Mu.Unlock()
+
+	var resource *ResourceError
+	if _, err := Copy(src, WithStrictResources()); !errors.As(err, &resource) {
+		t.Errorf("Expected a ResourceError for a locked mutex, got %v", err)
+	}
+}
+
+func TestCopy_WithTransform(t *testing.T) {
+	type User struct {
+		Name     string
+		Password string
+	}
+
+	type S struct {
+		Admin User
+		Users []User
+	}
+
+	src := S{Admin: User{Name: "root", Password: "secret"},
+		Users: []User{{Name: "alice", Password: "1234"}}}
+
+	var paths []string
+	redact := func(path string, v reflect.Value) (reflect.Value, bool) {
+		if strings.HasSuffix(path, "Password") {
+			paths = append(paths, path)
+			return reflect.ValueOf(""), true
+		}
+
+		return reflect.Value{}, false
+	}
+
+	dst, err := Copy(src, WithTransform(redact))
+	if err != nil {
+		t.Fatalf("Copy with WithTransform failed: %v", err)
+	}
+
+	expected := S{Admin: User{Name: "root"}, Users: []User{{Name: "alice"}}}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, dst)
+	}
+
+	if src.Admin.Password != "secret" || src.Users[0].Password != "1234" {
+		t.Errorf("Source was modified: %+v", src)
+	}
+
+	expectedPaths := []string{"Admin.Password", "Users[0].Password"}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("Expected paths %v, got %v", expectedPaths, paths)
+	}
+}
+
+func TestCopy_WithTransform_SliceElements(t *testing.T) {
+	double := func(path string, v reflect.Value) (reflect.Value, bool) {
+		if v.Kind() == reflect.Int {
+			return reflect.ValueOf(int(v.Int() * 2)), true
+		}
+
+		return reflect.Value{}, false
+	}
+
+	dst, err := Copy([]int{1, 2, 3}, WithTransform(double))
+	if err != nil {
+		t.Fatalf("Copy with WithTransform failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, []int{2, 4, 6}) {
+		t.Errorf("Expected [2 4 6], got %v", dst)
+	}
+}
+
+func TestCopy_WithTransform_IncompatibleType(t *testing.T) {
+	toInt := func(path string, v reflect.Value) (reflect.Value, bool) {
+		return reflect.ValueOf(42), path == "Name"
+	}
+
+	type S struct {
+		Name string
+	}
+
+	_, err := Copy(S{Name: "name"}, WithTransform(toInt))
+	var incompatible *IncompatibleValueError
+	if !errors.As(err, &incompatible) {
+		t.Fatalf("Expected IncompatibleValueError, got %v", err)
+	}
+	if incompatible.Path != "Name" ||
+		incompatible.Type != reflect.TypeFor[string]() ||
+		incompatible.ValueType != reflect.TypeFor[int]() {
+		t.Errorf("Unexpected error contents: %+v", incompatible)
+	}
+}
+
+type finalizedRecord struct {
+	Items []int
+	Sum   int
+}
+
+func TestCopy_WithFinalize(t *testing.T) {
+	src := finalizedRecord{Items: []int{1, 2, 3}}
+
+	calls := 0
+	finalize := WithFinalize(func(dst reflect.Value) error {
+		calls++
+		record := dst.Addr().Interface().(*finalizedRecord)
+		for _, item := range record.Items {
+			record.Sum += item
+		}
+		return nil
+	})
+
+	dst, err := Copy(src, finalize)
+	if err != nil {
+		t.Fatalf("Copy with WithFinalize failed: %v", err)
+	}
+
+	if dst.Sum != 6 || calls != 1 {
+		t.Errorf("Expected a single call setting Sum to 6, got %d after %d calls",
+			dst.Sum, calls)
+	}
+	if src.Sum != 0 {
+		t.Errorf("Expected source to be left untouched")
+	}
+
+	var into finalizedRecord
+	if err := CopyInto(&into, src, finalize); err != nil || into.Sum != 6 {
+		t.Errorf("Expected CopyInto to finalize the copy, got %d, %v",
+			into.Sum, err)
+	}
+
+	ptr, err := Copy(&src, WithFinalize(func(dst reflect.Value) error {
+		dst.Elem().FieldByName("Sum").SetInt(-1)
+		return nil
+	}))
+	if err != nil || ptr.Sum != -1 || src.Sum != 0 {
+		t.Errorf("Expected the copied pointer to be finalized, got %v", err)
+	}
+}
+
+func TestCopy_WithFinalize_Error(t *testing.T) {
+	errInvalid := errors.New("invalid record")
+
+	_, err := Copy(finalizedRecord{}, WithFinalize(func(reflect.Value) error {
+		return errInvalid
+	}))
+	if !errors.Is(err, errInvalid) {
+		t.Errorf("Expected the finalize error, got %v", err)
+	}
+
+	into := finalizedRecord{Sum: 1}
+	err = CopyInto(&into, finalizedRecord{}, WithFinalize(func(reflect.Value) error {
+		return errInvalid
+	}))
+	if !errors.Is(err, errInvalid) || into.Sum != 1 {
+		t.Errorf("Expected the finalize error and dst left untouched, got %v", err)
+	}
+}
+
+func TestCopy_WithErrorOnUintptr(t *testing.T) {
+	type S struct {
+		Handle uintptr
+		Spare  uintptr
+	}
+
+	src := S{Handle: 0xdeadbeef}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dst != src {
+		t.Errorf("Expected %+v, got %+v", src, dst)
+	}
+
+	var unsupported *UnsupportedTypeError
+	_, err = Copy(src, WithErrorOnUintptr())
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected an UnsupportedTypeError, got %v", err)
+	}
+	if unsupported.Path != "Handle" {
+		t.Errorf("Expected path Handle, got %q", unsupported.Path)
+	}
+
+	// Zero values can not hold pointers.
+	if _, err := Copy(S{}, WithErrorOnUintptr()); err != nil {
+		t.Errorf("Copy of zero uintptr failed: %v", err)
+	}
+
+	_, err = Copy([]uintptr{0, 1}, WithErrorOnUintptr())
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected an UnsupportedTypeError, got %v", err)
+	}
+	if unsupported.Path != "[1]" {
+		t.Errorf("Expected path [1], got %q", unsupported.Path)
+	}
+}
+
+func TestCopy_WithSortedMapKeys(t *testing.T) {
+	src := map[string]int{}
+	for _, key := range []string{"d", "b", "e", "a", "c", "f", "h", "g"} {
+		src[key] = len(src)
+	}
+
+	var paths []string
+	record := func(path string, v reflect.Value) (reflect.Value, bool) {
+		if v.Kind() == reflect.Int {
+			paths = append(paths, path)
+		}
+
+		return reflect.Value{}, false
+	}
+
+	for i := 0; i < 10; i++ {
+		paths = nil
+
+		dst, err := Copy(src, WithSortedMapKeys(), WithTransform(record))
+		if err != nil {
+			t.Fatalf("Copy with WithSortedMapKeys failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(dst, src) {
+			t.Fatalf("Expected %v, got %v", src, dst)
+		}
+
+		expected := []string{`["a"]`, `["b"]`, `["c"]`, `["d"]`, `["e"]`,
+			`["f"]`, `["g"]`, `["h"]`}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("Expected paths %v, got %v", expected, paths)
+		}
+	}
+}
+
+func TestSortMapKeys(t *testing.T) {
+	keys := reflect.ValueOf(map[int]bool{3: true, -1: true, 2: true}).MapKeys()
+	sortMapKeys(keys)
+
+	var got []int64
+	for _, key := range keys {
+		got = append(got, key.Int())
+	}
+
+	if !reflect.DeepEqual(got, []int64{-1, 2, 3}) {
+		t.Errorf("Expected [-1 2 3], got %v", got)
+	}
+}
+
+func TestCopy_WithPartialOnError(t *testing.T) {
+	type Item struct {
+		A     *int
+		F     func()
+		After int
+	}
+
+	type S struct {
+		Name  string
+		Items []Item
+		Last  int
+	}
+
+	a := 1
+	src := S{Name: "name", Items: []Item{{A: &a}, {A: &a, F: func() {}, After: 2}},
+		Last: 3}
+
+	var partial S
+	_, err := Copy(src, WithPartialOnError(&partial))
+
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected an UnsupportedTypeError, got %v", err)
+	}
+
+	if partial.Name != "name" {
+		t.Errorf("Expected name to be copied, got %q", partial.Name)
+	}
+	if len(partial.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(partial.Items))
+	}
+	if partial.Items[0].A == nil || *partial.Items[0].A != 1 ||
+		partial.Items[0].A == &a {
+		t.Errorf("Expected first item to be deep copied, got %+v", partial.Items[0])
+	}
+	if partial.Items[1].A != partial.Items[0].A {
+		t.Errorf("Expected second item to share the copied pointer")
+	}
+	if partial.Items[1].After != 0 || partial.Last != 0 {
+		t.Errorf("Expected values after the failure to be left zero, got %+v", partial)
+	}
+
+	// Nothing is written when the copy succeeds.
+	partial = S{}
+	if _, err := Copy(S{Name: "ok"}, WithPartialOnError(&partial)); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if partial.Name != "" {
+		t.Errorf("Expected no partial copy on success, got %+v", partial)
+	}
+}
+
+func TestCopy_WithErrorOnUnexported(t *testing.T) {
+	type Inner struct {
+		Public  int
+		private int
+	}
+
+	type S struct {
+		Items []Inner
+		_     int
+	}
+
+	src := S{Items: []Inner{{Public: 1, private: 2}}}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dst.Items[0].Public != 1 || dst.Items[0].private != 0 {
+		t.Errorf("Expected unexported field to be silently skipped, got %+v", dst.Items[0])
+	}
+
+	var unexported *UnexportedFieldError
+	_, err = Copy(src, WithErrorOnUnexported())
+	if !errors.As(err, &unexported) {
+		t.Fatalf("Expected an UnexportedFieldError, got %v", err)
+	}
+	if unexported.Field != "private" || unexported.Type != reflect.TypeFor[Inner]() {
+		t.Errorf("Expected field private of Inner, got %s of %v", unexported.Field,
+			unexported.Type)
+	}
+	if unexported.Path != "Items[0].private" {
+		t.Errorf("Expected path Items[0].private, got %q", unexported.Path)
+	}
+
+	// Blank fields and allowed unexported fields are not reported.
+	if _, err := Copy(src, WithErrorOnUnexported(),
+		WithUnexportedFieldsFor(reflect.TypeFor[Inner]().PkgPath())); err != nil {
+		t.Errorf("Copy failed: %v", err)
+	}
+}
+
+type internedRecord struct {
+	Country string
+	City    string
+	Tags    []string
+}
+
+func newInternedRecords(n int) []internedRecord {
+	records := make([]internedRecord, n)
+	for i := range records {
+		// Building the strings at run time gives every record its own copy
+		// of them.
+		records[i] = internedRecord{
+			Country: strings.Repeat("a", 32),
+			City:    strings.Repeat("b", 32),
+			Tags:    []string{strings.Repeat("c", 32), strings.Repeat("d", 32)},
+		}
+	}
+
+	return records
+}
+
+func TestCopy_WithStringInterning(t *testing.T) {
+	type Name string
+
+	src := newInternedRecords(3)
+
+	dst, err := Copy(src, WithStringInterning())
+	if err != nil {
+		t.Fatalf("Copy with WithStringInterning failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Fatalf("Expected %v, got %v", src, dst)
+	}
+
+	if unsafe.StringData(src[0].City) == unsafe.StringData(src[1].City) {
+		t.Fatalf("Expected source strings to not share memory")
+	}
+
+	for i := range dst {
+		if unsafe.StringData(dst[i].City) != unsafe.StringData(dst[0].City) ||
+			unsafe.StringData(dst[i].Tags[1]) != unsafe.StringData(dst[0].Tags[1]) {
+			t.Errorf("Expected equal strings to share memory in copy %d", i)
+		}
+	}
+
+	names, err := Copy([]any{"name", Name("name")},
+		WithStringInterning())
+	if err != nil {
+		t.Fatalf("Copy with WithStringInterning failed: %v", err)
+	}
+	if _, ok := names[1].(Name); !ok {
+		t.Errorf("Expected interned string to keep its type, got %T", names[1])
+	}
+}
+
+func benchmarkStringInterning(b *testing.B, opts ...Option) {
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		src := newInternedRecords(10_000)
+		b.StartTimer()
+
+		dst := MustCopy(src, opts...)
+
+		b.StopTimer()
+		src = nil
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained += after.HeapAlloc - before.HeapAlloc
+		runtime.KeepAlive(dst)
+		b.StartTimer()
+	}
+
+	b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+}
+
+func BenchmarkCopy_RepeatedStrings(b *testing.B) {
+	benchmarkStringInterning(b)
+}
+
+func BenchmarkCopy_RepeatedStrings_Interning(b *testing.B) {
+	benchmarkStringInterning(b, WithStringInterning())
+}
+
+type testLogger interface {
+	Log(msg string)
+}
+
+type recordingLogger struct {
+	Messages []string
+}
+
+func (l *recordingLogger) Log(msg string) {
+	l.Messages = append(l.Messages, msg)
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Log(string) {}
+
+func TestCopy_WithInterfaceFactory(t *testing.T) {
+	type S struct {
+		Logger testLogger
+		Other  any
+	}
+
+	noop := func(t reflect.Type) (reflect.Value, bool) {
+		if t == reflect.TypeFor[*recordingLogger]() {
+			return reflect.ValueOf(noopLogger{}), true
+		}
+
+		return reflect.Value{}, false
+	}
+
+	src := S{Logger: &recordingLogger{}, Other: &recordingLogger{}}
+
+	dst, err := Copy(src, WithInterfaceFactory(noop))
+	if err != nil {
+		t.Fatalf("Copy with WithInterfaceFactory failed: %v", err)
+	}
+
+	if _, ok := dst.Logger.(noopLogger); !ok {
+		t.Errorf("Expected logger to be replaced, got %T", dst.Logger)
+	}
+	if _, ok := dst.Other.(noopLogger); !ok {
+		t.Errorf("Expected other to be replaced, got %T", dst.Other)
+	}
+
+	dst, err = Copy(S{Logger: noopLogger{}}, WithInterfaceFactory(noop))
+	if err != nil {
+		t.Fatalf("Copy with WithInterfaceFactory failed: %v", err)
+	}
+	if _, ok := dst.Logger.(noopLogger); !ok {
+		t.Errorf("Expected logger to be copied, got %T", dst.Logger)
+	}
+}
+
+func TestCopy_WithInterfaceFactory_IncompatibleType(t *testing.T) {
+	type S struct {
+		Logger testLogger
+	}
+
+	toInt := func(t reflect.Type) (reflect.Value, bool) {
+		return reflect.ValueOf(42), true
+	}
+
+	_, err := Copy(S{Logger: noopLogger{}}, WithInterfaceFactory(toInt))
+	var incompatible *IncompatibleValueError
+	if !errors.As(err, &incompatible) {
+		t.Fatalf("Expected IncompatibleValueError, got %v", err)
+	}
+	if incompatible.Path != "Logger" ||
+		incompatible.Type != reflect.TypeFor[testLogger]() ||
+		incompatible.ValueType != reflect.TypeFor[int]() {
+		t.Errorf("Unexpected error contents: %+v", incompatible)
+	}
+}
+
+func TestCopy_WithSkipField(t *testing.T) {
+	type Event struct {
+		Name string
+		At   time.Time
+	}
+
+	type S struct {
+		Created time.Time
+		Events  []Event
+		private time.Time
+	}
+
+	now := time.Now()
+	src := S{Created: now, Events: []Event{{Name: "a", At: now}}, private: now}
+
+	var paths []string
+	skipTimes := func(path string, sf reflect.StructField) bool {
+		if sf.Type == reflect.TypeFor[time.Time]() {
+			paths = append(paths, path)
+			return true
+		}
+
+		return false
+	}
+
+	dst, err := Copy(src, WithSkipField(skipTimes), WithErrorOnUnexported())
+	if err != nil {
+		t.Fatalf("Copy with WithSkipField failed: %v", err)
+	}
+
+	expected := S{Events: []Event{{Name: "a"}}}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, dst)
+	}
+
+	expectedPaths := []string{"Created", "Events[0].At", "private"}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("Expected paths %v, got %v", expectedPaths, paths)
+	}
+}
+
+func TestCopy_WithInteriorPointers(t *testing.T) {
+	type Item struct {
+		Value int
+	}
+
+	type S struct {
+		Items    []Item
+		Selected *Item
+	}
+
+	src := S{Items: []Item{{Value: 1}, {Value: 2}}}
+	src.Selected = &src.Items[1]
+
+	// By default, the pointer gets its own copy of the element.
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dst.Selected == &dst.Items[1] || dst.Selected.Value != 2 {
+		t.Errorf("Expected pointer to a separate copy of the element")
+	}
+
+	dst, err = Copy(src, WithInteriorPointers())
+	if err != nil {
+		t.Fatalf("Copy with WithInteriorPointers failed: %v", err)
+	}
+	if dst.Selected != &dst.Items[1] {
+		t.Errorf("Expected pointer into the copied slice")
+	}
+	if dst.Selected == src.Selected {
+		t.Errorf("Expected pointer to not point into the source slice")
+	}
+
+	// Pointers found before the slice already have their own copy.
+	type Reversed struct {
+		Selected *Item
+		Items    []Item
+	}
+
+	items := []Item{{Value: 1}}
+	reversed, err := Copy(Reversed{Selected: &items[0], Items: items},
+		WithInteriorPointers())
+	if err != nil {
+		t.Fatalf("Copy with WithInteriorPointers failed: %v", err)
+	}
+	if reversed.Selected == &reversed.Items[0] || reversed.Selected.Value != 1 {
+		t.Errorf("Expected pointer to a separate copy of the element")
+	}
+}
+
+type immutableLimits struct {
+	Min, Max int64
+	Ratio    float64
+}
+
+type immutableSection struct {
+	Name    [16]byte
+	Limits  [16]immutableLimits
+	Enabled bool
+}
+
+type immutableConfig struct {
+	Version  int
+	Sections [32]immutableSection
+}
+
+func TestCopy_WithShareImmutable(t *testing.T) {
+	type S struct {
+		Config immutableConfig
+		Ptr    *immutableConfig
+	}
+
+	config := immutableConfig{Version: 1}
+	config.Sections[3].Limits[2].Max = 42
+	config.Sections[3].Enabled = true
+	src := S{Config: config, Ptr: &config}
+
+	// With WithStats, every nested value is visited by default.
+	var stats Stats
+	dst, err := Copy(src, WithStats(&stats))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dst.Config != config || stats.Nodes < 1000 {
+		t.Errorf("Expected every nested value to be copied, got %d nodes",
+			stats.Nodes)
+	}
+
+	stats = Stats{}
+	dst, err = Copy(src, WithShareImmutable(), WithStats(&stats))
+	if err != nil {
+		t.Fatalf("Copy with WithShareImmutable failed: %v", err)
+	}
+	if dst.Config != config || *dst.Ptr != config || stats.Nodes != 4 {
+		t.Errorf("Expected immutable values to be shared as they are, got %d nodes",
+			stats.Nodes)
+	}
+	if dst.Ptr == src.Ptr {
+		t.Errorf("Expected pointers to still be copied")
+	}
+}
+
+func TestCopy_WithShareImmutable_Excluded(t *testing.T) {
+	type Locked struct {
+		Mu sync.Mutex
+		N  int
+	}
+	type Tagged struct {
+		N     int
+		Cache int `deep:"-"`
+	}
+	type Private struct {
+		N int
+		n int
+	}
+	type S struct {
+		Locked  Locked
+		Tagged  Tagged
+		Private [1]Private
+		Counter atomic.Int64
+	}
+
+	src := &S{Tagged: Tagged{N: 1, Cache: 2}, Private: [1]Private{{N: 3, n: 4}}}
+	src.Locked.N = 5
+	src.Locked.Mu.Lock()
+	defer src.Locked.Mu.Unlock()
+	src.Counter.Store(6)
+
+	dst, err := Copy(src, WithShareImmutable())
+	if err != nil {
+		t.Fatalf("Copy with WithShareImmutable failed: %v", err)
+	}
+	if !dst.Locked.Mu.TryLock() {
+		t.Errorf("Expected the mutex to be reset")
+	}
+	if dst.Locked.N != 5 || dst.Counter.Load() != 6 {
+		t.Errorf("Expected values 5 and 6, got %d and %d", dst.Locked.N,
+			dst.Counter.Load())
+	}
+	if dst.Tagged != (Tagged{N: 1}) {
+		t.Errorf("Expected the tagged field to be skipped, got %v", dst.Tagged)
+	}
+	if dst.Private[0] != (Private{N: 3}) {
+		t.Errorf("Expected the unexported field to be skipped, got %v",
+			dst.Private[0])
+	}
+}
+
+func TestCopy_WithShareImmutable_CustomCopy(t *testing.T) {
+	type Counter struct {
+		N int
+	}
+
+	Register(func(c Counter) Counter { return Counter{N: c.N + 1} })
+	defer Unregister[Counter]()
+
+	type S struct {
+		Counters [2]Counter
+	}
+
+	dst, err := Copy(S{Counters: [2]Counter{{N: 1}, {N: 2}}},
+		WithShareImmutable())
+	if err != nil {
+		t.Fatalf("Copy with WithShareImmutable failed: %v", err)
+	}
+	if dst.Counters[0].N != 2 || dst.Counters[1].N != 3 {
+		t.Errorf("Expected registered copy function to be used, got %v",
+			dst.Counters)
+	}
+}
+
+func TestIsImmutable(t *testing.T) {
+	type withPointer struct {
+		_ [4]int
+		p *int
+	}
+
+	tests := []struct {
+		typ  reflect.Type
+		want bool
+	}{
+		{reflect.TypeFor[int](), true},
+		{reflect.TypeFor[string](), true},
+		{reflect.TypeFor[[4]float64](), true},
+		{reflect.TypeFor[immutableConfig](), true},
+		{reflect.TypeFor[time.Duration](), true},
+		{reflect.TypeFor[withPointer](), false},
+		{reflect.TypeFor[[2]withPointer](), false},
+		{reflect.TypeFor[[]int](), false},
+		{reflect.TypeFor[any](), false},
+		{reflect.TypeFor[bulkCopier](), false},
+		{reflect.TypeFor[[2]bulkCopier](), false},
+		{reflect.TypeFor[sync.Mutex](), false},
+		{reflect.TypeFor[sync.Once](), false},
+		{reflect.TypeFor[atomic.Int64](), false},
+		{reflect.TypeFor[struct{ n int }](), false},
+		{reflect.TypeFor[[2]struct {
+			N int `deep:"-"`
+		}](), false},
+	}
+
+	for _, tt := range tests {
+		if got := isImmutable(tt.typ); got != tt.want {
+			t.Errorf("isImmutable(%s) = %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkCopy_ImmutableTree(b *testing.B) {
+	src := &immutableConfig{}
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func BenchmarkCopy_ImmutableTree_ShareImmutable(b *testing.B) {
+	src := &immutableConfig{}
+	for i := 0; i < b.N; i++ {
+		MustCopy(src, WithShareImmutable())
+	}
+}
+
+func TestCopy_WithErrorHandler(t *testing.T) {
+	type S struct {
+		Func func()
+		Ch   chan int
+		N    int
+	}
+
+	src := S{Func: func() {}, Ch: make(chan int), N: 42}
+
+	type call struct {
+		path string
+		typ  reflect.Type
+	}
+
+	var calls []call
+	decide := func(decisions map[reflect.Kind]Decision) Option {
+		calls = nil
+		return WithErrorHandler(func(path string, typ reflect.Type, err error) Decision {
+			var unsupported *UnsupportedTypeError
+			if !errors.As(err, &unsupported) {
+				t.Errorf("Expected UnsupportedTypeError, got %v", err)
+			}
+			calls = append(calls, call{path: path, typ: typ})
+			return decisions[typ.Kind()]
+		})
+	}
+
+	// Share funcs but fail on channels.
+	_, err := Copy(src, decide(map[reflect.Kind]Decision{reflect.Func: Share,
+		reflect.Chan: Fail}))
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) || unsupported.Path != "Ch" {
+		t.Errorf("Expected UnsupportedTypeError at Ch, got %v", err)
+	}
+	want := []call{{"Func", reflect.TypeFor[func()]()},
+		{"Ch", reflect.TypeFor[chan int]()}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected handler calls %v, got %v", want, calls)
+	}
+
+	// Skip both.
+	var report []SkippedField
+	dst, err := Copy(src, decide(map[reflect.Kind]Decision{reflect.Func: Skip,
+		reflect.Chan: Skip}), WithSkipReport(&report))
+	if err != nil {
+		t.Fatalf("Copy with WithErrorHandler failed: %v", err)
+	}
+	if dst.Func != nil || dst.Ch != nil || dst.N != 42 {
+		t.Errorf("Expected func and channel to be skipped, got %+v", dst)
+	}
+	if len(report) != 2 {
+		t.Errorf("Expected 2 skipped values, got %v", report)
+	}
+
+	// Share both.
+	dst, err = Copy(src, decide(map[reflect.Kind]Decision{reflect.Func: Share,
+		reflect.Chan: Share}))
+	if err != nil {
+		t.Fatalf("Copy with WithErrorHandler failed: %v", err)
+	}
+	if dst.Func == nil || dst.Ch != src.Ch || dst.N != 42 {
+		t.Errorf("Expected func and channel to be shared, got %+v", dst)
+	}
+
+	// The handler takes precedence over WithSkipUnsupported.
+	_, err = Copy(src, WithSkipUnsupported(),
+		decide(map[reflect.Kind]Decision{}))
+	if !errors.As(err, &unsupported) || unsupported.Path != "Func" {
+		t.Errorf("Expected UnsupportedTypeError at Func, got %v", err)
+	}
+}
+
+func TestCopy_WithErrorHandler_CopierErr(t *testing.T) {
+	src := []CustomTypeForCopierErr{{Value: 1}, {Value: 2, Fail: true}}
+
+	var paths []string
+	dst, err := Copy(src, WithErrorHandler(
+		func(path string, _ reflect.Type, err error) Decision {
+			if !errors.Is(err, errCustomCopierErr) {
+				t.Errorf("Expected copier error, got %v", err)
+			}
+			paths = append(paths, path)
+			return Share
+		}))
+	if err != nil {
+		t.Fatalf("Copy with WithErrorHandler failed: %v", err)
+	}
+
+	if dst[0].Value != 2 || dst[1] != src[1] {
+		t.Errorf("Expected failing element to be shared, got %v", dst)
+	}
+	if len(paths) != 1 || paths[0] != "[1]" {
+		t.Errorf("Expected handler to be called for [1], got %v", paths)
+	}
+}
+
+func TestCopy_WithShareClosers(t *testing.T) {
+	type S struct {
+		Conn   net.Conn
+		Body   io.ReadCloser
+		Reader io.Reader
+	}
+
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+
+	src := S{
+		Conn:   conn,
+		Body:   io.NopCloser(strings.NewReader("body")),
+		Reader: strings.NewReader("reader"),
+	}
+
+	dst, err := Copy(src, WithShareClosers())
+	if err != nil {
+		t.Fatalf("Copy with WithShareClosers failed: %v", err)
+	}
+
+	if dst.Conn != src.Conn || dst.Body != src.Body {
+		t.Errorf("Expected values implementing io.Closer to be shared")
+	}
+	if dst.Reader == src.Reader {
+		t.Errorf("Expected other interface values to be deep copied")
+	}
+
+	// The shared connection is still usable.
+	go peer.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(dst.Conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("Expected to read ping from the shared connection, got %q (%v)",
+			buf, err)
+	}
+
+	// Without the option, the connection is copied.
+	dst, err = Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dst.Conn == src.Conn {
+		t.Errorf("Expected connection to be copied without WithShareClosers")
+	}
+}
+
+func TestCopy_WithTimeInUTC(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone database not available: %v", err)
+	}
+
+	type S struct {
+		At    time.Time
+		Ptr   *time.Time
+		Times []time.Time
+	}
+
+	at := time.Date(2025, 7, 1, 9, 30, 0, 0, newYork)
+	src := S{At: at, Ptr: &at, Times: []time.Time{at}}
+
+	dst, err := Copy(src, WithTimeInUTC())
+	if err != nil {
+		t.Fatalf("Copy with WithTimeInUTC failed: %v", err)
+	}
+
+	for _, copied := range []time.Time{dst.At, *dst.Ptr, dst.Times[0]} {
+		if copied.Location() != time.UTC {
+			t.Errorf("Expected copy to be in UTC, got %s", copied.Location())
+		}
+		if !copied.Equal(at) {
+			t.Errorf("Expected copy to be the same instant as %s, got %s", at,
+				copied)
+		}
+	}
+	if src.At.Location() != newYork {
+		t.Errorf("Expected source to be unchanged")
+	}
+
+	// By default, the location is kept.
+	dst, err = Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dst.At.Location() != newYork {
+		t.Errorf("Expected copy to keep its location, got %s", dst.At.Location())
+	}
+}
+
+func TestCopy_WithSharedBackingArrays(t *testing.T) {
+	type Buffers struct {
+		All    []int
+		Window []int
+		Tail   []int
+	}
+
+	all := make([]int, 6, 8)
+	for i := range all {
+		all[i] = i
+	}
+	src := Buffers{All: all, Window: all[2:5], Tail: all[6:8]}
+
+	// By default, each slice gets its own backing array.
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if &dst.Window[0] == &dst.All[2] {
+		t.Errorf("Expected slices to be copied independently")
+	}
+
+	dst, err = Copy(src, WithSharedBackingArrays())
+	if err != nil {
+		t.Fatalf("Copy with WithSharedBackingArrays failed: %v", err)
+	}
+
+	if len(dst.Window) != 3 || cap(dst.Window) != 6 {
+		t.Fatalf("Expected len 3 and cap 6, got len %d and cap %d",
+			len(dst.Window), cap(dst.Window))
+	}
+	if &dst.Window[0] != &dst.All[2] {
+		t.Errorf("Expected window to point into the copied array")
+	}
+	if &dst.Tail[0] != &dst.All[:8][6] {
+		t.Errorf("Expected tail to point into the copied array past its length")
+	}
+	if &dst.All[0] == &src.All[0] {
+		t.Errorf("Expected the backing array to be copied")
+	}
+
+	dst.Window[0] = 100
+	if dst.All[2] != 100 || src.All[2] != 2 {
+		t.Errorf("Expected writes to be shared only within the copy")
+	}
+
+	// Slices found before the slice holding them get their own copy.
+	reversed, err := Copy(Buffers{All: all[2:5], Window: all},
+		WithSharedBackingArrays())
+	if err != nil {
+		t.Fatalf("Copy with WithSharedBackingArrays failed: %v", err)
+	}
+	if &reversed.All[0] == &reversed.Window[2] {
+		t.Errorf("Expected slices found first to be copied independently")
+	}
+	if reversed.All[0] != 2 || reversed.Window[2] != 2 {
+		t.Errorf("Expected elements to be copied")
+	}
+}
+
+func TestCopy_WithSharedBackingArrays_NamedTypes(t *testing.T) {
+	type Values []int
+
+	type S struct {
+		All    []int
+		Window Values
+	}
+
+	all := []int{0, 1, 2, 3}
+	dst, err := Copy(S{All: all, Window: all[1:]}, WithSharedBackingArrays())
+	if err != nil {
+		t.Fatalf("Copy with WithSharedBackingArrays failed: %v", err)
+	}
+
+	if &dst.Window[0] != &dst.All[1] {
+		t.Errorf("Expected slices of different types to share the array")
+	}
+}
+
+func TestCopy_WithInPlaceSliceReuse(t *testing.T) {
+	type Item struct {
+		Name string
+		Tags []string
+	}
+
+	src := []Item{{Name: "a", Tags: []string{"x"}}, {Name: "b"}}
+	existing := make([]Item, 5)
+
+	dst, err := Copy(src, WithInPlaceSliceReuse(existing))
+	if err != nil {
+		t.Fatalf("Copy with WithInPlaceSliceReuse failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Expected %v, got %v", src, dst)
+	}
+	if &dst[0] != &existing[0] {
+		t.Errorf("Expected the copy to be made into the existing slice")
+	}
+	if &dst[0].Tags[0] == &src[0].Tags[0] {
+		t.Errorf("Expected nested slices to be copied")
+	}
+
+	// Slices without enough capacity are not used.
+	small := make([]Item, 1)
+	dst, err = Copy(src, WithInPlaceSliceReuse(small))
+	if err != nil {
+		t.Fatalf("Copy with WithInPlaceSliceReuse failed: %v", err)
+	}
+	if !reflect.DeepEqual(dst, src) || &dst[0] == &small[0] {
+		t.Errorf("Expected a new slice when the existing one is too small")
+	}
+	if small[0].Name != "" {
+		t.Errorf("Expected the small slice to be left as it is")
+	}
+}
+
+func TestCopy_WithInPlaceSliceReuse_InterfaceRoot(t *testing.T) {
+	var src any = []int{1, 2}
+	existing := make([]int, 2)
+
+	dst, err := Copy(src, WithInPlaceSliceReuse(existing))
+	if err != nil {
+		t.Fatalf("Copy with WithInPlaceSliceReuse failed: %v", err)
+	}
+
+	values, ok := dst.([]int)
+	if !ok || !reflect.DeepEqual(values, src) {
+		t.Fatalf("Expected %v, got %v", src, dst)
+	}
+	if &values[0] != &existing[0] {
+		t.Errorf("Expected the slice held by the root interface to be made into the existing one")
+	}
+}
+
+func TestCopy_WithInPlaceSliceReuse_Mismatch(t *testing.T) {
+	_, err := Copy([]int{1, 2}, WithInPlaceSliceReuse(make([]string, 2)))
+
+	var incompatible *IncompatibleValueError
+	if !errors.As(err, &incompatible) {
+		t.Errorf("Expected IncompatibleValueError, got %v", err)
+	}
+
+	// Only the top-level slice is made into the existing one.
+	type S struct {
+		Values []int
+	}
+
+	existing := make([]int, 2)
+	dst, err := Copy(S{Values: []int{1, 2}}, WithInPlaceSliceReuse(existing))
+	if err != nil {
+		t.Fatalf("Copy with WithInPlaceSliceReuse failed: %v", err)
+	}
+	if &dst.Values[0] == &existing[0] {
+		t.Errorf("Expected nested slices not to use the existing slice")
+	}
+}
+
+func TestCopyEach_WithInPlaceSliceReuse(t *testing.T) {
+	src := [][]int{{1, 2}, {3, 4}}
+	existing := make([]int, 2)
+
+	var copies [][]int
+	err := CopyEach(src, func(i int, copy []int) error {
+		copies = append(copies, copy)
+		return nil
+	}, WithInPlaceSliceReuse(existing))
+	if err != nil {
+		t.Fatalf("CopyEach with WithInPlaceSliceReuse failed: %v", err)
+	}
+
+	// Each element gets its own slice, instead of all of them sharing the
+	// existing one.
+	if !reflect.DeepEqual(copies, src) {
+		t.Errorf("Expected %v, got %v", src, copies)
+	}
+	if &copies[0][0] == &copies[1][0] || &copies[0][0] == &existing[0] {
+		t.Errorf("Expected the copies not to share a slice")
+	}
+}
+
+func benchmarkInPlaceSliceReuse(b *testing.B, reuse bool) {
+	src := make([]int64, 1_000_000)
+	for i := range src {
+		src[i] = int64(i)
+	}
+
+	var dst []int64
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var opts []Option
+		if reuse {
+			opts = append(opts, WithInPlaceSliceReuse(dst))
+		}
+
+		dst = MustCopy(src, opts...)
+	}
+}
+
+func BenchmarkCopy_RepeatedSlice(b *testing.B) {
+	benchmarkInPlaceSliceReuse(b, false)
+}
+
+func BenchmarkCopy_RepeatedSlice_InPlaceReuse(b *testing.B) {
+	benchmarkInPlaceSliceReuse(b, true)
+}
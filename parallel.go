@@ -0,0 +1,91 @@
+package deep
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// copySliceElemsParallel copies the elements of src into dst, splitting them
+// into contiguous chunks that are copied concurrently by up to cfg.parallel
+// workers. Each worker uses its own pointers map and a copy of cfg, so src
+// elements must not hold references that could be shared between them.
+func copySliceElemsParallel(dst, src reflect.Value, cfg *config) error {
+	n := src.Len()
+	workers := min(cfg.parallel, n)
+	chunk := (n + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	workerCfgs := make([]config, workers)
+	workerReports := make([][]SkippedField, workers)
+	workerStats := make([]Stats, workers)
+	for w := 0; w < workers; w++ {
+		low := w * chunk
+		high := min(low+chunk, n)
+		if low >= high {
+			break
+		}
+
+		wg.Add(1)
+		go func(w, low, high int) {
+			defer wg.Done()
+
+			workerCfg := &workerCfgs[w]
+			*workerCfg = *cfg
+			workerCfg.temporaries = nil
+			workerCfg.interned = nil
+			// Clipping makes appends to the path allocate a new backing
+			// array instead of racing on the shared one.
+			workerCfg.path = slices.Clip(cfg.path)
+			if cfg.skipReport != nil {
+				workerCfg.skipReport = &workerReports[w]
+			}
+			if cfg.stats != nil {
+				workerCfg.stats = &workerStats[w]
+			}
+
+			pointers := make(pointersMap)
+			for i := low; i < high; i++ {
+				workerCfg.pushIndex(i)
+				elemDst, err := recursiveCopy(src.Index(i), pointers,
+					workerCfg)
+				workerCfg.popPath()
+				if err != nil {
+					errs[w] = inIndex(err, i)
+					return
+				}
+
+				dst.Index(i).Set(elemDst)
+			}
+		}(w, low, high)
+	}
+
+	wg.Wait()
+
+	for i := range workerCfgs {
+		cfg.temporaries = append(cfg.temporaries,
+			workerCfgs[i].temporaries...)
+	}
+
+	if cfg.stats != nil {
+		for _, stats := range workerStats {
+			cfg.stats.add(stats)
+		}
+	}
+
+	if cfg.skipReport != nil {
+		for _, report := range workerReports {
+			*cfg.skipReport = append(*cfg.skipReport, report...)
+		}
+	}
+
+	// Report the error for the lowest failing index, as a serial copy would.
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package deep
+
+import (
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type parallelElem struct {
+	ID    int
+	Name  string
+	Score float64
+	Tags  [4]int
+}
+
+func newParallelSlice(n int) []parallelElem {
+	src := make([]parallelElem, n)
+	for i := range src {
+		src[i] = parallelElem{ID: i, Name: "name", Score: float64(i),
+			Tags: [4]int{i, i + 1, i + 2, i + 3}}
+	}
+
+	return src
+}
+
+func TestCopy_WithParallel(t *testing.T) {
+	src := newParallelSlice(10001)
+
+	dst, err := Copy(src, WithParallel(8))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Parallel copy differs from source")
+	}
+
+	if cap(dst) != cap(src) {
+		t.Errorf("Expected capacity %d, got %d", cap(src), cap(dst))
+	}
+
+	dst[0].ID = -1
+	if src[0].ID != 0 {
+		t.Errorf("Expected copy to be independent from source")
+	}
+}
+
+// parallelProbe records whether two of its values are copied at the same
+// time, waiting for a while for another one to be copied.
+type parallelProbe struct {
+	ID int
+}
+
+var (
+	parallelProbesActive atomic.Int32
+	parallelProbesMet    atomic.Bool
+)
+
+func (p parallelProbe) DeepCopy() interface{} {
+	parallelProbesActive.Add(1)
+	defer parallelProbesActive.Add(-1)
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		if parallelProbesActive.Load() > 1 {
+			parallelProbesMet.Store(true)
+		}
+		if parallelProbesMet.Load() {
+			break
+		}
+		runtime.Gosched()
+	}
+
+	return p
+}
+
+func TestCopy_WithParallel_InterfaceRoot(t *testing.T) {
+	parallelProbesMet.Store(false)
+
+	var src any = []parallelProbe{{ID: 1}, {ID: 2}}
+	dst, err := Copy(src, WithParallel(2))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Parallel copy differs from source")
+	}
+	if !parallelProbesMet.Load() {
+		t.Errorf("Expected the slice held by the root interface to be copied in parallel")
+	}
+}
+
+func TestCopy_WithParallel_MoreWorkersThanElements(t *testing.T) {
+	src := newParallelSlice(3)
+
+	dst, err := Copy(src, WithParallel(16))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Parallel copy differs from source")
+	}
+}
+
+func TestCopy_WithParallel_Error(t *testing.T) {
+	type S struct {
+		A int
+		B [1]func()
+	}
+
+	src := make([]S, 100)
+	src[50].B[0] = func() {}
+
+	if _, err := Copy(src, WithParallel(4)); err == nil {
+		t.Errorf("Parallel copy did not fail on unsupported element")
+	}
+}
+
+func TestCopy_WithParallel_SharedPointersFallBackToSerial(t *testing.T) {
+	shared := 42
+	src := []*int{&shared, &shared}
+
+	dst, err := Copy(src, WithParallel(2))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	// Identity is preserved because elements with references are copied
+	// serially.
+	if dst[0] != dst[1] {
+		t.Errorf("Expected shared pointer to be preserved")
+	}
+}
+
+func BenchmarkCopy_Slice_Serial(b *testing.B) {
+	src := newParallelSlice(1000000)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func BenchmarkCopy_Slice_Parallel(b *testing.B) {
+	src := newParallelSlice(1000000)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src, WithParallel(8))
+	}
+}
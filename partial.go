@@ -0,0 +1,34 @@
+package deep
+
+import "reflect"
+
+// failed returns the result of a copy that failed with err: dst, the copy
+// built so far, if WithPartialOnError was given, or the invalid value
+// otherwise.
+func (cfg *config) failed(dst reflect.Value, err error) (reflect.Value, error) {
+	if !cfg.partial.IsValid() {
+		return reflect.Value{}, err
+	}
+
+	return dst, err
+}
+
+// setPartial sets dst to the partial copy of a value that failed to be copied,
+// if there is one.
+func (cfg *config) setPartial(dst, partial reflect.Value) {
+	if partial.IsValid() {
+		dst.Set(partial)
+	}
+}
+
+// writePartial writes the partial copy of the root value to the destination
+// given to WithPartialOnError, if any.
+func (cfg *config) writePartial(partial reflect.Value) {
+	if !cfg.partial.IsValid() || !partial.IsValid() {
+		return
+	}
+
+	if partial.Type().AssignableTo(cfg.partial.Type()) {
+		cfg.partial.Set(partial)
+	}
+}
@@ -0,0 +1,151 @@
+package deep
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// pathSegmentKind is the kind of step taken from a value to one of its
+// children.
+type pathSegmentKind int
+
+const (
+	pathField pathSegmentKind = iota
+	pathIndex
+	pathKey
+)
+
+// pathSegment is a single step in the path from the root value being copied to
+// the current one.
+type pathSegment struct {
+	kind  pathSegmentKind
+	name  string
+	index int
+	key   reflect.Value
+}
+
+// The path to the value being copied is only tracked when options that are
+// given it need it (see tracksPath). Errors do not need it: they are created
+// with an empty path, and every value they are returned through prepends the
+// step taken to its child with withSegment.
+
+// tracksPath reports whether the options need the path to the value being
+// copied while the copy is in progress.
+func (cfg *config) tracksPath() bool {
+	return cfg.transform != nil || cfg.skipField != nil ||
+		cfg.errorHandler != nil || cfg.tracer != nil ||
+		cfg.skipReport != nil || cfg.fieldDefaults != nil
+}
+
+func (cfg *config) pushField(name string) {
+	if cfg.trackPath {
+		cfg.path = append(cfg.path, pathSegment{kind: pathField, name: name})
+	}
+}
+
+func (cfg *config) pushIndex(index int) {
+	if cfg.trackPath {
+		cfg.path = append(cfg.path, pathSegment{kind: pathIndex, index: index})
+	}
+}
+
+func (cfg *config) pushKey(key reflect.Value) {
+	if cfg.trackPath {
+		cfg.path = append(cfg.path, pathSegment{kind: pathKey, key: key})
+	}
+}
+
+func (cfg *config) popPath() {
+	if cfg.trackPath {
+		cfg.path = cfg.path[:len(cfg.path)-1]
+	}
+}
+
+// currentPath renders the path to the value currently being copied, in Go
+// selector syntax relative to the root value (e.g. `Items[2].Tags["a"]`). The
+// root value itself has an empty path.
+func (cfg *config) currentPath() string {
+	var sb strings.Builder
+	for _, segment := range cfg.path {
+		if sb.Len() > 0 && segment.kind == pathField {
+			sb.WriteByte('.')
+		}
+		segment.writeTo(&sb)
+	}
+
+	return sb.String()
+}
+
+// writeTo renders the segment to sb.
+func (segment pathSegment) writeTo(sb *strings.Builder) {
+	switch segment.kind {
+	case pathField:
+		sb.WriteString(segment.name)
+	case pathIndex:
+		sb.WriteByte('[')
+		sb.WriteString(strconv.Itoa(segment.index))
+		sb.WriteByte(']')
+	case pathKey:
+		sb.WriteByte('[')
+		sb.WriteString(formatPathKey(segment.key))
+		sb.WriteByte(']')
+	}
+}
+
+// pathError is implemented by the errors that report the path to the value
+// they are about.
+type pathError interface {
+	error
+	pathRef() *string
+}
+
+// withSegment prepends segment to the path reported by err, which is returned
+// from the child the segment leads to, and returns err. Errors wrapping one
+// that reports a path have that one updated.
+func withSegment(err error, segment pathSegment) error {
+	var pathErr pathError
+	if !errors.As(err, &pathErr) {
+		return err
+	}
+
+	path := pathErr.pathRef()
+
+	var sb strings.Builder
+	segment.writeTo(&sb)
+	if *path != "" && (*path)[0] != '[' {
+		sb.WriteByte('.')
+	}
+	sb.WriteString(*path)
+	*path = sb.String()
+
+	return err
+}
+
+// inField, inIndex and inKey prepend the struct field, the index or the map
+// key the error err was found at to its path.
+func inField(err error, name string) error {
+	return withSegment(err, pathSegment{kind: pathField, name: name})
+}
+
+func inIndex(err error, index int) error {
+	return withSegment(err, pathSegment{kind: pathIndex, index: index})
+}
+
+func inKey(err error, key reflect.Value) error {
+	return withSegment(err, pathSegment{kind: pathKey, key: key})
+}
+
+func formatPathKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return strconv.Quote(key.String())
+	}
+
+	if key.CanInterface() {
+		return fmt.Sprintf("%v", key.Interface())
+	}
+
+	return key.Type().String()
+}
@@ -0,0 +1,55 @@
+package deep
+
+import (
+	"reflect"
+	"sync"
+)
+
+// valuePools maps a reflect.Type to a *sync.Pool of pointers to values of that
+// type. The values in the pools are always zero.
+var valuePools sync.Map
+
+// temporary is a pooled value obtained during a copy.
+type temporary struct {
+	// ptr is the pointer to the value taken from pool.
+	ptr  reflect.Value
+	pool *sync.Pool
+}
+
+// newTemporary returns a new addressable zero value of the given type. The
+// returned value is only used to build a copy that is then set into its
+// final location, so when pooling is enabled it is taken from a per-type pool
+// and returned to it once the copy completes.
+func newTemporary(t reflect.Type, cfg *config) reflect.Value {
+	if !cfg.reusePool {
+		return reflect.New(t).Elem()
+	}
+
+	pool, ok := valuePools.Load(t)
+	if !ok {
+		pool, _ = valuePools.LoadOrStore(t, &sync.Pool{
+			New: func() any {
+				return reflect.New(t).Interface()
+			},
+		})
+	}
+
+	ptr := reflect.ValueOf(pool.(*sync.Pool).Get())
+	cfg.temporaries = append(cfg.temporaries,
+		temporary{ptr: ptr, pool: pool.(*sync.Pool)})
+
+	return ptr.Elem()
+}
+
+// releaseTemporaries resets all the pooled values obtained during the copy and
+// returns them to their pools, so the pools do not keep the data copied into
+// them alive. It must only be called once the copy result no longer
+// references them.
+func (cfg *config) releaseTemporaries() {
+	for _, temp := range cfg.temporaries {
+		temp.ptr.Elem().SetZero()
+		temp.pool.Put(temp.ptr.Interface())
+	}
+
+	cfg.temporaries = nil
+}
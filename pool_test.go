@@ -0,0 +1,159 @@
+package deep
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type pooledInner struct {
+	A int
+	B string
+	C *int
+}
+
+type pooledOuter struct {
+	X pooledInner
+	Y pooledInner
+	Z [4]pooledInner
+}
+
+func newPooledOuter() pooledOuter {
+	c := 42
+	src := pooledOuter{
+		X: pooledInner{A: 1, B: "x", C: &c},
+		Y: pooledInner{A: 2, B: "y"},
+	}
+	for i := range src.Z {
+		src.Z[i] = pooledInner{A: i, B: "z"}
+	}
+
+	return src
+}
+
+func TestCopy_WithReusePool(t *testing.T) {
+	src := newPooledOuter()
+
+	for i := 0; i < 10; i++ {
+		dst, err := Copy(src, WithReusePool())
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(dst, src) {
+			t.Fatalf("Pooled copy differs from source: %+v", dst)
+		}
+
+		if dst.X.C == src.X.C {
+			t.Fatalf("Expected pointer to be deep copied")
+		}
+	}
+}
+
+func TestCopy_WithReusePool_NoLeaks(t *testing.T) {
+	type S struct {
+		A int
+		b int
+		C int `deep:"-"`
+	}
+
+	// Fill the pool with values holding data that must not leak into later
+	// copies through the fields that are not copied.
+	for i := 0; i < 10; i++ {
+		if _, err := Copy(S{A: 1, b: 2, C: 3}, WithReusePool()); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+	}
+
+	dst, err := Copy(S{A: 4}, WithReusePool())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst != (S{A: 4}) {
+		t.Errorf("Expected pooled value to be reset, got %+v", dst)
+	}
+}
+
+func TestCopy_WithReusePool_ResetOnRelease(t *testing.T) {
+	src := newPooledOuter()
+	if _, err := Copy(src, WithReusePool()); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	// The pooled values must not keep the copied data alive.
+	pool, ok := valuePools.Load(reflect.TypeFor[pooledInner]())
+	if !ok {
+		t.Fatalf("Expected a pool for pooledInner")
+	}
+	for i := 0; i < 6; i++ {
+		if v := pool.(*sync.Pool).Get().(*pooledInner); *v != (pooledInner{}) {
+			t.Errorf("Expected pooled value to be reset, got %+v", *v)
+		}
+	}
+}
+
+func TestCopyInto_WithReusePool(t *testing.T) {
+	src := newPooledOuter()
+
+	var dst pooledOuter
+	if err := CopyInto(&dst, src, WithReusePool()); err != nil {
+		t.Fatalf("CopyInto failed: %v", err)
+	}
+
+	// Copying again must not affect the previous result.
+	if _, err := Copy(pooledOuter{}, WithReusePool()); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Pooled copy differs from source: %+v", dst)
+	}
+}
+
+func BenchmarkCopy_HotStruct(b *testing.B) {
+	src := newPooledOuter()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func BenchmarkCopy_HotStruct_ReusePool(b *testing.B) {
+	src := newPooledOuter()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src, WithReusePool())
+	}
+}
+
+// pooledLarge holds a pointer, so its values are not trivially copyable and a
+// temporary is used for each of them.
+type pooledLarge struct {
+	Buf  [4096]byte
+	Next *int
+}
+
+func BenchmarkCopy_LargeStruct(b *testing.B) {
+	src := &[8]pooledLarge{}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func BenchmarkCopy_LargeStruct_ReusePool(b *testing.B) {
+	src := &[8]pooledLarge{}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src, WithReusePool())
+	}
+}
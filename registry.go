@@ -0,0 +1,174 @@
+package deep
+
+import (
+	"os"
+	"reflect"
+	"sync"
+)
+
+// copyFunc copies a value of a registered type, using pointers and cfg for the
+// copies of its children.
+type copyFunc func(v reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error)
+
+// registry maps a reflect.Type to the registeredCopy for it.
+var registry sync.Map
+
+// registeredCopy is the copy function registered for a type.
+type registeredCopy struct {
+	fn copyFunc
+	// full is set for functions registered with RegisterFull, which copy the
+	// children of the value with the pointers map of the copy.
+	full bool
+}
+
+// call copies v with the registered function. Only functions registered with
+// RegisterFull are given the pointers map, so the children they copy share it
+// with the rest of the copy.
+func (r registeredCopy) call(v reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	if !r.full {
+		return r.fn(v, nil, cfg)
+	}
+
+	return r.fn(v, pointers, cfg)
+}
+
+// Register sets fn as the function used to copy values of type T, taking
+// precedence over any Copier implementation and the default copy logic. This
+// allows providing copy logic for types that can not be changed to implement
+// Copier, like types from third-party packages. Registering a function for a
+// type that already has one replaces it.
+func Register[T any](fn func(T) T) {
+	defer clearTypeInfos()
+
+	registry.Store(reflect.TypeFor[T](), registeredCopy{fn: func(v reflect.Value,
+		_ pointersMap, _ *config) (reflect.Value, error) {
+		// The assertion fails for nil interfaces, which are passed to fn as
+		// the zero value.
+		src, _ := v.Interface().(T)
+		dst := fn(src)
+
+		// Going through a pointer keeps the type as T even if it is an
+		// interface type.
+		return reflect.ValueOf(&dst).Elem(), nil
+	}})
+}
+
+// RegisterFull is like Register, but fn can fail and is given copyChild to
+// deep copy the values held by the value being copied as part of the same
+// copy. This keeps pointer identity with the rest of the copied value, so
+// pointers shared with other values are copied only once, and options like
+// WithMaxDepth apply to the children. copyChild returns a copy of the same type
+// as the given value, and nil for nil. The value being copied is only known
+// to the rest of the copy once fn returns, so a child referencing it back
+// would be copied again.
+func RegisterFull[T any](fn func(src T,
+	copyChild func(interface{}) (interface{}, error)) (T, error)) {
+	defer clearTypeInfos()
+
+	registry.Store(reflect.TypeFor[T](), registeredCopy{fn: func(v reflect.Value,
+		pointers pointersMap, cfg *config) (reflect.Value, error) {
+		copyChild := func(child interface{}) (interface{}, error) {
+			if child == nil {
+				return nil, nil
+			}
+
+			dst, err := recursiveCopy(reflect.ValueOf(child), pointers, cfg)
+			if err != nil || !dst.IsValid() {
+				return nil, err
+			}
+
+			return dst.Interface(), nil
+		}
+
+		src, _ := v.Interface().(T)
+		dst, err := fn(src, copyChild)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&dst).Elem(), nil
+	}, full: true})
+}
+
+// Unregister removes the copy function registered for type T, if any.
+func Unregister[T any]() {
+	defer clearTypeInfos()
+
+	registry.Delete(reflect.TypeFor[T]())
+}
+
+// registeredCopyFunc returns the copy function registered for the given type.
+func registeredCopyFunc(t reflect.Type) (registeredCopy, bool) {
+	registered, ok := registry.Load(t)
+	if !ok {
+		return registeredCopy{}, false
+	}
+
+	return registered.(registeredCopy), true
+}
+
+// shallowTypes holds the types registered with RegisterShallow.
+var shallowTypes sync.Map
+
+// RegisterShallow makes values of type t always be copied by direct
+// assignment instead of being deep copied. This is useful for types that are
+// effectively immutable, where deep copying is wasted work. For a pointer
+// type, this means the pointer itself is shared between source and copy.
+func RegisterShallow(t reflect.Type) {
+	defer clearTypeInfos()
+
+	shallowTypes.Store(t, struct{}{})
+}
+
+// UnregisterShallow undoes a previous RegisterShallow for type t.
+func UnregisterShallow(t reflect.Type) {
+	defer clearTypeInfos()
+
+	shallowTypes.Delete(t)
+}
+
+// resourceTypes holds the types registered with RegisterResource.
+var resourceTypes sync.Map
+
+func init() {
+	RegisterResource(reflect.TypeFor[*os.File]())
+}
+
+// RegisterResource makes non-zero values of type t be treated as holding a
+// resource that can not be meaningfully copied, so copies using
+// WithStrictResources fail when they find one. For a pointer type, this means
+// any non-nil pointer.
+func RegisterResource(t reflect.Type) {
+	resourceTypes.Store(t, struct{}{})
+}
+
+// UnregisterResource undoes a previous RegisterResource for type t.
+func UnregisterResource(t reflect.Type) {
+	resourceTypes.Delete(t)
+}
+
+// isResource reports whether v holds a resource that can not be meaningfully
+// copied, as checked by WithStrictResources.
+func isResource(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Func, reflect.Chan:
+		return !v.IsNil()
+	}
+
+	switch v.Type() {
+	case reflect.TypeFor[sync.Mutex](), reflect.TypeFor[sync.RWMutex]():
+		// Trying to lock a copy tells whether the value is locked without
+		// touching the value itself.
+		locker := reflect.New(v.Type())
+		locker.Elem().Set(v)
+		return !locker.MethodByName("TryLock").Call(nil)[0].Bool()
+	}
+
+	if _, ok := resourceTypes.Load(v.Type()); ok {
+		return !v.IsZero()
+	}
+
+	return false
+}
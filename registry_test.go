@@ -0,0 +1,315 @@
+package deep
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegister(t *testing.T) {
+	type S struct {
+		Timeout time.Duration
+		Other   int
+	}
+
+	calls := 0
+	Register(func(d time.Duration) time.Duration {
+		calls++
+		return d * 2
+	})
+	t.Cleanup(Unregister[time.Duration])
+
+	dst, err := Copy(S{Timeout: time.Second, Other: 1})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected registered function to be called once, got %d", calls)
+	}
+
+	if dst.Timeout != 2*time.Second {
+		t.Errorf("Expected Timeout to be 2s, got %s", dst.Timeout)
+	}
+
+	if dst.Other != 1 {
+		t.Errorf("Expected Other to be 1, got %d", dst.Other)
+	}
+}
+
+func TestRegister_OverridesCopier(t *testing.T) {
+	Register(func(ct CustomTypeForCopier) CustomTypeForCopier {
+		return CustomTypeForCopier{Value: -ct.Value}
+	})
+	t.Cleanup(Unregister[CustomTypeForCopier])
+
+	dst, err := Copy(CustomTypeForCopier{Value: 10})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Value != -10 {
+		t.Errorf("Expected registered function to take precedence, got %d", dst.Value)
+	}
+}
+
+func TestRegister_InterfaceType(t *testing.T) {
+	type S struct {
+		R io.Reader
+	}
+
+	Register(func(r io.Reader) io.Reader {
+		// Readers can not be copied, so share them.
+		return r
+	})
+	t.Cleanup(Unregister[io.Reader])
+
+	src := S{R: strings.NewReader("data")}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.R != src.R {
+		t.Errorf("Expected reader to be shared")
+	}
+
+	// The registered function also applies to a root value of the interface
+	// type.
+	reader, err := Copy[io.Reader](src.R)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if reader != src.R {
+		t.Errorf("Expected root reader to be shared")
+	}
+
+	if _, err := Copy(S{}); err != nil {
+		t.Errorf("Copy of nil reader failed: %v", err)
+	}
+}
+
+type fullCopied struct {
+	Shared *int
+	Extra  any
+	id     int
+}
+
+func TestRegisterFull(t *testing.T) {
+	type S struct {
+		Custom fullCopied
+		Shared *int
+	}
+
+	RegisterFull(func(src fullCopied,
+		copyChild func(interface{}) (interface{}, error)) (fullCopied, error) {
+		shared, err := copyChild(src.Shared)
+		if err != nil {
+			return fullCopied{}, err
+		}
+
+		extra, err := copyChild(src.Extra)
+		if err != nil {
+			return fullCopied{}, err
+		}
+
+		// The unexported field is kept, which the default copy would not do.
+		return fullCopied{Shared: shared.(*int), Extra: extra, id: src.id}, nil
+	})
+	t.Cleanup(Unregister[fullCopied])
+
+	value := 42
+	src := S{Custom: fullCopied{Shared: &value, Extra: []int{1}, id: 7},
+		Shared: &value}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Custom.id != 7 {
+		t.Errorf("Expected registered function to be used")
+	}
+	if dst.Shared == src.Shared || dst.Custom.Shared != dst.Shared {
+		t.Errorf("Expected the shared pointer to be copied once and shared")
+	}
+	if extra := dst.Custom.Extra.([]int); len(extra) != 1 || extra[0] != 1 {
+		t.Errorf("Expected extra to be copied, got %v", dst.Custom.Extra)
+	}
+
+	// Errors from copying children are returned by the copy.
+	src.Custom.Extra = func() {}
+	_, err = Copy(src)
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) {
+		t.Errorf("Expected UnsupportedTypeError, got %v", err)
+	}
+
+	// Children see the options of the copy.
+	dst, err = Copy(src, WithShareFuncs())
+	if err != nil {
+		t.Fatalf("Copy with WithShareFuncs failed: %v", err)
+	}
+	if dst.Custom.Extra == nil {
+		t.Errorf("Expected func to be shared")
+	}
+}
+
+// BenchmarkCopy_RegisterFull_Slice copies many values copied with RegisterFull
+// in the same copy, each adding its children to the pointers map.
+func BenchmarkCopy_RegisterFull_Slice(b *testing.B) {
+	RegisterFull(func(src fullCopied,
+		copyChild func(interface{}) (interface{}, error)) (fullCopied, error) {
+		shared, err := copyChild(src.Shared)
+		if err != nil {
+			return fullCopied{}, err
+		}
+
+		return fullCopied{Shared: shared.(*int), id: src.id}, nil
+	})
+	b.Cleanup(Unregister[fullCopied])
+
+	src := make([]fullCopied, 16384)
+	for i := range src {
+		value := i
+		src[i] = fullCopied{Shared: &value, id: i}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MustCopy(src)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	Register(func(i int) int {
+		return i + 1
+	})
+	Unregister[int]()
+
+	dst, err := Copy(42)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst != 42 {
+		t.Errorf("Expected unregistered function to not be called, got %d", dst)
+	}
+}
+
+type shallowID struct {
+	Value string
+}
+
+func TestRegisterShallow(t *testing.T) {
+	type S struct {
+		ID    *shallowID
+		Other *int
+	}
+
+	typ := reflect.TypeFor[*shallowID]()
+	RegisterShallow(typ)
+	t.Cleanup(func() { UnregisterShallow(typ) })
+
+	other := 42
+	src := S{ID: &shallowID{Value: "id"}, Other: &other}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.ID != src.ID {
+		t.Errorf("Expected registered shallow pointer to be shared")
+	}
+
+	if dst.Other == src.Other {
+		t.Errorf("Expected other pointers to be deep copied")
+	}
+}
+
+func TestUnregisterShallow(t *testing.T) {
+	typ := reflect.TypeFor[*shallowID]()
+	RegisterShallow(typ)
+	UnregisterShallow(typ)
+
+	src := &shallowID{Value: "id"}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst == src {
+		t.Errorf("Expected unregistered type to be deep copied")
+	}
+}
+
+func TestCopy_WithShallowTypes(t *testing.T) {
+	type S struct {
+		Table map[string]int
+		Items []int
+	}
+
+	src := S{Table: map[string]int{"a": 1}, Items: []int{1}}
+
+	dst, err := Copy(src, WithShallowTypes(reflect.TypeFor[map[string]int]()))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if reflect.ValueOf(dst.Table).Pointer() != reflect.ValueOf(src.Table).Pointer() {
+		t.Errorf("Expected shallow map to be shared")
+	}
+
+	if &dst.Items[0] == &src.Items[0] {
+		t.Errorf("Expected other slices to be deep copied")
+	}
+
+	// The option only applies to the copy it was given to.
+	dst, err = Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if reflect.ValueOf(dst.Table).Pointer() == reflect.ValueOf(src.Table).Pointer() {
+		t.Errorf("Expected map to be deep copied without the option")
+	}
+}
+
+type resourceHandle struct {
+	FD int
+}
+
+func TestRegisterResource(t *testing.T) {
+	type S struct {
+		Handle resourceHandle
+	}
+
+	typ := reflect.TypeFor[resourceHandle]()
+	RegisterResource(typ)
+	t.Cleanup(func() { UnregisterResource(typ) })
+
+	if _, err := Copy(S{}, WithStrictResources()); err != nil {
+		t.Errorf("Copy with zero registered resource failed: %v", err)
+	}
+
+	var resource *ResourceError
+	_, err := Copy(S{Handle: resourceHandle{FD: 3}}, WithStrictResources())
+	if !errors.As(err, &resource) {
+		t.Fatalf("Expected a ResourceError, got %v", err)
+	}
+	if resource.Path != "Handle" {
+		t.Errorf("Expected path Handle, got %q", resource.Path)
+	}
+
+	// Without the option, registered resources are copied as usual.
+	if _, err := Copy(S{Handle: resourceHandle{FD: 3}}); err != nil {
+		t.Errorf("Copy failed: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package deep
+
+// Scope holds the pointer identity shared by a sequence of copies made with
+// CopyWithScope. Values referenced from several copies made with the same
+// Scope are copied only once, so the copies reference the same (copied)
+// values, just like with CopyMany. This allows keeping copies made at
+// different places consistent with each other. A Scope must not be used by
+// several copies concurrently.
+type Scope struct {
+	pointers pointersMap
+}
+
+// NewScope returns a new, empty Scope.
+func NewScope() *Scope {
+	return &Scope{pointers: make(pointersMap)}
+}
+
+// CopyWithScope creates a deep copy of src sharing pointer identity with all
+// the other copies made with scope. It returns the copy and a nil error in case
+// of success and the zero value for the type and a non-nil error on failure. A
+// failed copy may leave partial copies of values in scope, which later copies
+// would reuse. The behavior of the copy can be adjusted with the given
+// options.
+func CopyWithScope[T any](scope *Scope, src T, opts ...Option) (T, error) {
+	return copyWithPointers(src, scope.pointers, newConfig(opts))
+}
@@ -0,0 +1,53 @@
+package deep
+
+import "testing"
+
+func TestCopyWithScope(t *testing.T) {
+	type Account struct {
+		ID int
+	}
+
+	type Order struct {
+		Account *Account
+	}
+
+	type Invoice struct {
+		Account *Account
+		Orders  []*Order
+	}
+
+	account := &Account{ID: 1}
+	order := &Order{Account: account}
+	invoice := Invoice{Account: account, Orders: []*Order{order}}
+
+	scope := NewScope()
+
+	dstOrder, err := CopyWithScope(scope, order)
+	if err != nil {
+		t.Fatalf("CopyWithScope failed: %v", err)
+	}
+
+	dstInvoice, err := CopyWithScope(scope, invoice)
+	if err != nil {
+		t.Fatalf("CopyWithScope failed: %v", err)
+	}
+
+	if dstOrder == order || dstOrder.Account == account {
+		t.Errorf("Expected order to be deep copied")
+	}
+	if dstInvoice.Account != dstOrder.Account {
+		t.Errorf("Expected copies to share the copied account")
+	}
+	if dstInvoice.Orders[0] != dstOrder {
+		t.Errorf("Expected copies to share the copied order")
+	}
+
+	// Copies made with another scope are independent.
+	other, err := CopyWithScope(NewScope(), order)
+	if err != nil {
+		t.Fatalf("CopyWithScope failed: %v", err)
+	}
+	if other == dstOrder || other.Account == dstOrder.Account {
+		t.Errorf("Expected copies with other scopes to be independent")
+	}
+}
@@ -0,0 +1,44 @@
+package deep
+
+import "reflect"
+
+// EstimateSize returns the approximate number of bytes taken by a deep copy of
+// src, or the error Copy would return. It walks src exactly like CanCopy, so
+// nothing is allocated, and values referenced several times are only counted
+// once. The estimate adds up the size of T itself, the values pointed to, the
+// backing arrays of slices (up to their capacity), the keys and values of
+// maps, the bytes of strings (even though copies share them with the source)
+// and the values held by interfaces. The internal overhead of maps and, like
+// with CanCopy, whatever custom copy logic allocates are not counted. The
+// behavior of the walk can be adjusted with the given options, like with Copy.
+func EstimateSize[T any](src T, opts ...Option) (int64, error) {
+	var size int64
+
+	cfg := newConfig(opts)
+	cfg.dryRun = true
+	cfg.partial = reflect.Value{}
+	cfg.size = &size
+
+	if _, err := copyInternal(src, cfg); err != nil {
+		return 0, err
+	}
+
+	return int64(reflect.TypeFor[T]().Size()) + size, nil
+}
+
+func (cfg *config) countSize(n int64) {
+	if cfg.size != nil {
+		*cfg.size += n
+	}
+}
+
+// countBoxedSize counts the size of a value of type t held by an interface,
+// unless it fits in the interface itself.
+func (cfg *config) countBoxedSize(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func,
+		reflect.UnsafePointer:
+	default:
+		cfg.countSize(int64(t.Size()))
+	}
+}
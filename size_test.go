@@ -0,0 +1,119 @@
+package deep
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func TestEstimateSize(t *testing.T) {
+	type S struct {
+		Name   string
+		Values []int64
+		Ptr    *int64
+		M      map[string]int32
+	}
+
+	n := int64(1)
+	src := S{
+		Name:   "hello",
+		Values: make([]int64, 3, 4),
+		Ptr:    &n,
+		M:      map[string]int32{"a": 1, "bc": 2},
+	}
+
+	size, err := EstimateSize(src)
+	if err != nil {
+		t.Fatalf("EstimateSize failed: %v", err)
+	}
+
+	// S itself, the name, the backing array of the slice, the pointed to
+	// value, the map entries and the bytes of their keys.
+	expected := int64(unsafe.Sizeof(src) + 5 + 4*unsafe.Sizeof(int64(0)) +
+		unsafe.Sizeof(n) + 2*(unsafe.Sizeof("")+unsafe.Sizeof(int32(0))) + 3)
+	if size != expected {
+		t.Errorf("Expected size %d, got %d", expected, size)
+	}
+}
+
+func TestEstimateSize_Shared(t *testing.T) {
+	type Node struct {
+		Value [4]int64
+		Next  *Node
+	}
+
+	type S struct {
+		A, B *Node
+	}
+
+	node := &Node{}
+	node.Next = node
+
+	size, err := EstimateSize(S{A: node, B: node})
+	if err != nil {
+		t.Fatalf("EstimateSize failed: %v", err)
+	}
+
+	// S itself and the node, counted once.
+	expected := int64(unsafe.Sizeof(S{}) + unsafe.Sizeof(*node))
+	if size != expected {
+		t.Errorf("Expected size %d, got %d", expected, size)
+	}
+}
+
+func TestEstimateSize_Interfaces(t *testing.T) {
+	type pair struct{ A, B int32 }
+	src := []any{int64(1), "abc", &pair{}}
+
+	size, err := EstimateSize(src)
+	if err != nil {
+		t.Fatalf("EstimateSize failed: %v", err)
+	}
+
+	// The slice header, the backing array, the boxed int64, the boxed string
+	// header and its bytes, and the pointed to struct.
+	expected := int64(unsafe.Sizeof(src) + 3*unsafe.Sizeof(src[0]) +
+		unsafe.Sizeof(int64(0)) + unsafe.Sizeof("") + 3 + unsafe.Sizeof(pair{}))
+	if size != expected {
+		t.Errorf("Expected size %d, got %d", expected, size)
+	}
+}
+
+func TestEstimateSize_Strings(t *testing.T) {
+	type S struct {
+		Names [2]string
+	}
+
+	// Trivially copyable values and slices of strings are still walked.
+	size, err := EstimateSize([]S{{Names: [2]string{"a", "bc"}}})
+	if err != nil {
+		t.Fatalf("EstimateSize failed: %v", err)
+	}
+	expected := int64(unsafe.Sizeof([]S{}) + unsafe.Sizeof(S{}) + 3)
+	if size != expected {
+		t.Errorf("Expected size %d, got %d", expected, size)
+	}
+
+	size, err = EstimateSize([]string{"abc", "de"})
+	if err != nil {
+		t.Fatalf("EstimateSize failed: %v", err)
+	}
+	expected = int64(unsafe.Sizeof([]string{}) + 2*unsafe.Sizeof("") + 5)
+	if size != expected {
+		t.Errorf("Expected size %d, got %d", expected, size)
+	}
+}
+
+func TestEstimateSize_Error(t *testing.T) {
+	type S struct {
+		F func()
+	}
+
+	size, err := EstimateSize(S{F: func() {}})
+
+	var unsupported *UnsupportedTypeError
+	if !errors.As(err, &unsupported) || size != 0 {
+		t.Errorf("Expected UnsupportedTypeError and size 0, got %d (%v)", size,
+			err)
+	}
+}
@@ -0,0 +1,49 @@
+package deep
+
+// Stats holds statistics about the copies made with WithStats. They are
+// accumulated, so the same Stats can be used for several copies.
+type Stats struct {
+	// Nodes is the number of values visited, as limited by WithMaxNodes.
+	Nodes int
+	// DedupedPointers is the number of times a pointer, map, slice or channel
+	// that was already copied was found again, so its copy was reused.
+	DedupedPointers int
+	// Allocations is the number of pointers, maps, slices and channels
+	// allocated for the copies.
+	Allocations int
+	// MaxDepth is the deepest nesting level reached, as limited by
+	// WithMaxDepth.
+	MaxDepth int
+}
+
+// add accumulates the statistics in other into s.
+func (s *Stats) add(other Stats) {
+	s.Nodes += other.Nodes
+	s.DedupedPointers += other.DedupedPointers
+	s.Allocations += other.Allocations
+	s.MaxDepth = max(s.MaxDepth, other.MaxDepth)
+}
+
+func (cfg *config) countVisited(n int) {
+	if cfg.stats != nil {
+		cfg.stats.Nodes += n
+	}
+}
+
+func (cfg *config) countDeduped() {
+	if cfg.stats != nil {
+		cfg.stats.DedupedPointers++
+	}
+}
+
+func (cfg *config) countAllocation() {
+	if cfg.stats != nil {
+		cfg.stats.Allocations++
+	}
+}
+
+func (cfg *config) countDepth() {
+	if cfg.stats != nil {
+		cfg.stats.MaxDepth = max(cfg.stats.MaxDepth, cfg.depth)
+	}
+}
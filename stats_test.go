@@ -0,0 +1,70 @@
+package deep
+
+import (
+	"testing"
+)
+
+type statsLeaf struct {
+	X, Y int
+}
+
+type statsRoot struct {
+	A, B, C *statsLeaf
+	Name    string
+}
+
+func TestCopy_WithStats(t *testing.T) {
+	shared := &statsLeaf{X: 1, Y: 2}
+	src := statsRoot{A: shared, B: shared, C: &statsLeaf{}, Name: "root"}
+
+	var stats Stats
+	if _, err := Copy(src, WithStats(&stats)); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	// The root, the name, three pointers and two leaves with two fields each.
+	expected := Stats{Nodes: 11, DedupedPointers: 1, Allocations: 2,
+		MaxDepth: 3}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+
+	// Stats are accumulated over copies.
+	if _, err := Copy([]int{1, 2, 3}, WithStats(&stats)); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	expected = Stats{Nodes: 15, DedupedPointers: 1, Allocations: 3,
+		MaxDepth: 3}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+func TestCopy_WithStats_Parallel(t *testing.T) {
+	src := make([]statsLeaf, 100)
+
+	var serial, parallel Stats
+	if _, err := Copy(src, WithStats(&serial)); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if _, err := Copy(src, WithStats(&parallel), WithParallel(4)); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if serial != parallel {
+		t.Errorf("Expected stats %+v, got %+v", serial, parallel)
+	}
+}
+
+func BenchmarkCopy_HotStruct_Stats(b *testing.B) {
+	src := newPooledOuter()
+
+	var stats Stats
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		MustCopy(src, WithStats(&stats))
+	}
+}
@@ -0,0 +1,58 @@
+package deep
+
+import (
+	"reflect"
+	"sync"
+)
+
+var syncMapType = reflect.TypeFor[sync.Map]()
+
+// recursiveCopySyncMap copies the sync.Map v into dst by ranging over it and
+// storing deep copies of all its keys and values. The copy is a point-in-time
+// snapshot and is not atomic with respect to concurrent writers of v.
+func recursiveCopySyncMap(v, dst reflect.Value, pointers pointersMap,
+	cfg *config) (reflect.Value, error) {
+	// Range and Store have pointer receivers.
+	src := addressable(v).Addr().Interface().(*sync.Map)
+	dstMap := dst.Addr().Interface().(*sync.Map)
+
+	var err error
+	src.Range(func(key, value any) bool {
+		var keyDst, valueDst reflect.Value
+
+		keyDst, err = recursiveCopy(reflect.ValueOf(key), pointers, cfg)
+		if err != nil {
+			return false
+		}
+
+		// Custom copy logic can return keys Store would panic on (e.g. nil
+		// or not comparable ones), so those are reported instead.
+		if !keyDst.IsValid() || !keyDst.Comparable() {
+			err = cfg.incompatible(reflect.TypeOf(key), keyDst)
+			return false
+		}
+
+		if value == nil {
+			dstMap.Store(keyDst.Interface(), nil)
+			return true
+		}
+
+		valueDst, err = recursiveCopy(reflect.ValueOf(value), pointers, cfg)
+		if err != nil {
+			return false
+		}
+		if !valueDst.IsValid() {
+			err = cfg.incompatible(reflect.TypeOf(value), valueDst)
+			return false
+		}
+
+		dstMap.Store(keyDst.Interface(), valueDst.Interface())
+
+		return true
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return dst, nil
+}
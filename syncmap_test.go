@@ -0,0 +1,113 @@
+package deep
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCopy_SyncMap(t *testing.T) {
+	type Value struct {
+		N int
+	}
+
+	type S struct {
+		M sync.Map
+	}
+
+	src := &S{}
+	src.M.Store("a", &Value{N: 1})
+	src.M.Store(2, []int{2})
+	src.M.Store("nil", nil)
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	a, ok := dst.M.Load("a")
+	if !ok {
+		t.Fatalf("Expected key a to be copied")
+	}
+	srcA, _ := src.M.Load("a")
+	if a.(*Value).N != 1 || a == srcA {
+		t.Errorf("Expected an independent copy of the value for key a")
+	}
+
+	two, ok := dst.M.Load(2)
+	if !ok || len(two.([]int)) != 1 || two.([]int)[0] != 2 {
+		t.Errorf("Expected key 2 to be copied, got %v", two)
+	}
+
+	if value, ok := dst.M.Load("nil"); !ok || value != nil {
+		t.Errorf("Expected key nil to be copied with a nil value")
+	}
+
+	// The copy is independent from the source.
+	src.M.Store("b", 3)
+	if _, ok := dst.M.Load("b"); ok {
+		t.Errorf("Expected copy to be independent from source")
+	}
+
+	a.(*Value).N = 100
+	if srcA.(*Value).N != 1 {
+		t.Errorf("Expected copied values to be independent from source")
+	}
+}
+
+func TestCopy_SyncMap_Error(t *testing.T) {
+	src := &sync.Map{}
+	src.Store("f", func() {})
+
+	if _, err := Copy(src); err == nil {
+		t.Errorf("Copy did not fail on unsupported value")
+	}
+}
+
+func TestCopy_SyncMap_IncompatibleCopy(t *testing.T) {
+	type Key struct {
+		V any
+	}
+
+	nilKey := &sync.Map{}
+	nilKey.Store(NilCopier{}, 1)
+
+	nilValue := &sync.Map{}
+	nilValue.Store(1, NilCopier{})
+
+	// The copy of the key holds a slice, so it can not be hashed.
+	incomparable := &sync.Map{}
+	incomparable.Store(Key{V: 1}, 1)
+	toSlice := WithTransform(func(path string, v reflect.Value) (reflect.Value, bool) {
+		if v.Type() == reflect.TypeFor[Key]() {
+			return reflect.ValueOf(Key{V: []int{1}}), true
+		}
+		return reflect.Value{}, false
+	})
+
+	tests := []struct {
+		name string
+		src  *sync.Map
+		opts []Option
+		typ  reflect.Type
+	}{
+		{"NilKey", nilKey, nil, reflect.TypeFor[NilCopier]()},
+		{"NilValue", nilValue, nil, reflect.TypeFor[NilCopier]()},
+		{"Incomparable", incomparable, []Option{toSlice}, reflect.TypeFor[Key]()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Copy(tt.src, tt.opts...)
+
+			var incompatible *IncompatibleValueError
+			if !errors.As(err, &incompatible) {
+				t.Fatalf("Expected IncompatibleValueError, got %v", err)
+			}
+			if incompatible.Type != tt.typ {
+				t.Errorf("Expected type %s, got %s", tt.typ, incompatible.Type)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package deep
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// TraceEventKind is the kind of step of a copy a TraceEvent reports.
+type TraceEventKind int
+
+const (
+	// TraceEnterStruct is emitted when a struct starts being copied field by
+	// field. Structs made only of plain values are copied with a single
+	// assignment instead, which is not reported.
+	TraceEnterStruct TraceEventKind = iota
+	// TraceCopyPointer is emitted when a pointer that was not copied before
+	// starts being copied.
+	TraceCopyPointer
+	// TraceDedupHit is emitted when a pointer, map, slice or channel that was
+	// already copied is found again, so its copy is reused. These are the
+	// ones counted in the DedupedPointers of Stats.
+	TraceDedupHit
+	// TraceSkip is emitted when a value is skipped, like the ones recorded
+	// with WithSkipReport.
+	TraceSkip
+)
+
+// String returns the name of the kind, without the Trace prefix.
+func (k TraceEventKind) String() string {
+	switch k {
+	case TraceEnterStruct:
+		return "EnterStruct"
+	case TraceCopyPointer:
+		return "CopyPointer"
+	case TraceDedupHit:
+		return "DedupHit"
+	case TraceSkip:
+		return "Skip"
+	default:
+		return "TraceEventKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// TraceEvent describes a step of a copy, as reported to WithTracer.
+type TraceEvent struct {
+	// Kind is what happened.
+	Kind TraceEventKind
+	// Path is the location of the value relative to the root value being
+	// copied, in the same format as the Path of UnsupportedTypeError.
+	Path string
+	// Type is the type of the value.
+	Type reflect.Type
+}
+
+// WithTracer makes fn be called with an event for each notable step of the
+// copy, in the order they happen. This helps diagnosing how values are copied
+// in production, e.g. by recording the events in tracing spans. With
+// WithParallel, fn may be called concurrently.
+func WithTracer(fn func(event TraceEvent)) Option {
+	return func(cfg *config) {
+		cfg.tracer = fn
+	}
+}
+
+// trace reports an event of the given kind for the value of type t being
+// copied, if there is a tracer.
+func (cfg *config) trace(kind TraceEventKind, t reflect.Type) {
+	if cfg.tracer != nil {
+		cfg.tracer(TraceEvent{Kind: kind, Path: cfg.currentPath(), Type: t})
+	}
+}
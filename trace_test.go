@@ -0,0 +1,58 @@
+package deep
+
+import (
+	"reflect"
+	"testing"
+)
+
+type traceLeaf struct {
+	Values []int
+}
+
+type traceRoot struct {
+	A, B *traceLeaf
+	Done chan struct{}
+}
+
+func TestCopy_WithTracer(t *testing.T) {
+	shared := &traceLeaf{Values: []int{1}}
+	src := traceRoot{A: shared, B: shared, Done: make(chan struct{})}
+
+	var events []TraceEvent
+	_, err := Copy(src, WithSkipUnsupported(),
+		WithTracer(func(event TraceEvent) {
+			events = append(events, event)
+		}))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	rootType := reflect.TypeFor[traceRoot]()
+	leafType := reflect.TypeFor[traceLeaf]()
+	expected := []TraceEvent{
+		{Kind: TraceEnterStruct, Path: "", Type: rootType},
+		{Kind: TraceCopyPointer, Path: "A", Type: reflect.PointerTo(leafType)},
+		{Kind: TraceEnterStruct, Path: "A", Type: leafType},
+		{Kind: TraceDedupHit, Path: "B", Type: reflect.PointerTo(leafType)},
+		{Kind: TraceSkip, Path: "Done", Type: reflect.TypeFor[chan struct{}]()},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+}
+
+func TestTraceEventKind_String(t *testing.T) {
+	tests := map[TraceEventKind]string{
+		TraceEnterStruct:   "EnterStruct",
+		TraceCopyPointer:   "CopyPointer",
+		TraceDedupHit:      "DedupHit",
+		TraceSkip:          "Skip",
+		TraceEventKind(42): "TraceEventKind(42)",
+	}
+
+	for kind, expected := range tests {
+		if got := kind.String(); got != expected {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package deep
+
+import (
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// typeInfo is what the copy needs to know about a type to decide how to copy
+// its values. It is computed once per type, as doing it for every value would
+// take most of the time spent copying.
+type typeInfo struct {
+	// shallow is set for the types given to RegisterShallow.
+	shallow bool
+	// sharedPointer is set for pointers to one of the sharedTypes.
+	sharedPointer bool
+	// stdlibError is set for the error types from the standard library.
+	stdlibError bool
+	// registered is the function registered for the type, if hasRegistered.
+	registered    registeredCopy
+	hasRegistered bool
+	// copier is set if the type implements one of the Copier variants.
+	copier bool
+	// typedCopier is the DeepCopy() method implementing TypedCopier for the
+	// type, if any.
+	typedCopier reflect.Value
+	// pointerCopier is set if only pointers to the type implement one of the
+	// Copier variants.
+	pointerCopier bool
+	// customCopy is set if values of the type may be copied with custom
+	// logic.
+	customCopy bool
+	// special is set for the struct types copied with their own semantics
+	// instead of field by field.
+	special bool
+	// fields is the field metadata for struct types.
+	fields []fieldInfo
+	// references is set if values of the type can hold references, as
+	// reported by hasReferences.
+	references bool
+	// trivial and immutable tell whether values of the type can be shared
+	// with their copy, as reported by isTriviallyCopyable and isImmutable.
+	trivial   bool
+	immutable bool
+	// children caches the typeInfo for the types of the fields of struct
+	// types, by index, and of the elements of other container types, at
+	// index 0, followed by the keys for maps. They are looked up on first
+	// use, as recursive types would never be done otherwise.
+	children []atomic.Pointer[typeInfo]
+}
+
+// child returns the typeInfo for t, which is the type of the field at index i
+// for struct types, the element type with i 0 for arrays, maps, pointers and
+// slices, or the key type with i 1 for maps. This saves looking up the type of
+// every nested value in typeInfos.
+func (info *typeInfo) child(i int, t reflect.Type) *typeInfo {
+	if child := info.children[i].Load(); child != nil {
+		return child
+	}
+
+	child := infoFor(t)
+	info.children[i].Store(child)
+
+	return child
+}
+
+// typeInfos holds the map from reflect.Type to *typeInfo. It is read for
+// every copied value, so it is never modified once stored and is replaced by
+// an updated copy instead, under typeInfosMu. It is cleared whenever the
+// registered copy functions or shallow types change.
+var (
+	typeInfos   atomic.Pointer[map[reflect.Type]*typeInfo]
+	typeInfosMu sync.Mutex
+)
+
+// clearTypeInfos drops all the cached typeInfo values.
+func clearTypeInfos() {
+	typeInfosMu.Lock()
+	defer typeInfosMu.Unlock()
+
+	typeInfos.Store(nil)
+}
+
+// infoFor returns the typeInfo for the type t, computing and caching it on
+// first use.
+func infoFor(t reflect.Type) *typeInfo {
+	if infos := typeInfos.Load(); infos != nil {
+		if info, ok := (*infos)[t]; ok {
+			return info
+		}
+	}
+
+	info := &typeInfo{
+		stdlibError:   isStdlibError(t),
+		typedCopier:   typedCopierMethod(t),
+		pointerCopier: implementsPointerCopier(t),
+		special:       isSpecialStruct(t),
+		references:    holdsReferences(t),
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		info.fields = fieldsOf(t)
+		info.children = make([]atomic.Pointer[typeInfo], t.NumField())
+	case reflect.Array, reflect.Ptr, reflect.Slice:
+		info.children = make([]atomic.Pointer[typeInfo], 1)
+	case reflect.Map:
+		info.children = make([]atomic.Pointer[typeInfo], 2)
+	}
+	_, info.shallow = shallowTypes.Load(t)
+	if t.Kind() == reflect.Ptr {
+		_, info.sharedPointer = sharedTypes[t.Elem()]
+	}
+	info.registered, info.hasRegistered = registeredCopyFunc(t)
+
+	for _, copierType := range copierTypes {
+		if t.Implements(copierType) {
+			info.copier = true
+			break
+		}
+	}
+
+	_, hasDeepCopy := t.MethodByName("DeepCopy")
+	_, hasDeepCopyInto := t.MethodByName("DeepCopyInto")
+	info.customCopy = hasDeepCopy || hasDeepCopyInto || info.pointerCopier ||
+		info.hasRegistered
+
+	// Nested types are looked up on their own, and values can not contain
+	// themselves, so this always ends.
+	info.trivial = !info.customCopy && triviallyCopyable(t, info)
+	info.immutable = !info.customCopy && immutable(t, info)
+
+	typeInfosMu.Lock()
+	defer typeInfosMu.Unlock()
+
+	var infos map[reflect.Type]*typeInfo
+	if current := typeInfos.Load(); current != nil {
+		if actual, ok := (*current)[t]; ok {
+			return actual
+		}
+		infos = maps.Clone(*current)
+	} else {
+		infos = make(map[reflect.Type]*typeInfo)
+	}
+	infos[t] = info
+	typeInfos.Store(&infos)
+
+	return info
+}
+
+// isSpecialStruct reports whether t is one of the struct types copied by
+// recursiveCopySpecialStruct.
+func isSpecialStruct(t reflect.Type) bool {
+	if _, ok := specialStructTypes[t]; ok {
+		return true
+	}
+	if _, ok := resetTypes[t]; ok {
+		return true
+	}
+
+	return isAtomicType(t)
+}
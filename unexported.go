@@ -0,0 +1,42 @@
+package deep
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// copiesUnexportedFields reports whether the unexported fields of the struct
+// type t must be copied, as requested with WithUnexportedFieldsFor.
+func (cfg *config) copiesUnexportedFields(t reflect.Type) bool {
+	if len(cfg.unexportedFieldsFor) == 0 {
+		return false
+	}
+
+	_, ok := cfg.unexportedFieldsFor[structPkgPath(t)]
+
+	return ok
+}
+
+// structPkgPath returns the import path of the package defining the struct type
+// t. Anonymous struct types are not defined in any package, so the package
+// declaring their unexported fields is used instead (they can only be accessed
+// from it), or none if all of them are exported.
+func structPkgPath(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.PkgPath()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if pkgPath := t.Field(i).PkgPath; pkgPath != "" {
+			return pkgPath
+		}
+	}
+
+	return ""
+}
+
+// exposeField returns a value for the addressable struct field f that can be
+// read and set even if the field is unexported.
+func exposeField(f reflect.Value) reflect.Value {
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
@@ -0,0 +1,135 @@
+package deep
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type privateFields struct {
+	Public  int
+	private int
+	items   []int
+	next    *privateFields
+}
+
+func TestCopy_WithUnexportedFieldsFor(t *testing.T) {
+	src := privateFields{Public: 1, private: 2, items: []int{3},
+		next: &privateFields{private: 4}}
+
+	dst, err := Copy(src, WithUnexportedFieldsFor(reflect.TypeFor[privateFields]().PkgPath()))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Expected %+v, got %+v", src, dst)
+	}
+
+	if &dst.items[0] == &src.items[0] || dst.next == src.next {
+		t.Errorf("Expected unexported fields to be deep copied")
+	}
+}
+
+func TestCopy_WithUnexportedFieldsFor_OtherPackage(t *testing.T) {
+	type S struct {
+		Own    privateFields
+		Reader strings.Reader
+	}
+
+	src := S{Own: privateFields{private: 1}, Reader: *strings.NewReader("data")}
+
+	dst, err := Copy(src, WithUnexportedFieldsFor("example.com/other"))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Own.private != 0 {
+		t.Errorf("Expected unexported field of non-listed package to be skipped")
+	}
+
+	dst, err = Copy(src, WithUnexportedFieldsFor(reflect.TypeFor[S]().PkgPath()))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Own.private != 1 {
+		t.Errorf("Expected unexported field of listed package to be copied")
+	}
+
+	if dst.Reader.Len() != 0 {
+		t.Errorf("Expected unexported fields of strings.Reader to be skipped")
+	}
+}
+
+type secretElem struct {
+	Value  int
+	hidden int
+}
+
+type genericContainer[T any] struct {
+	Items []T
+	Index map[string]*T
+	Last  T
+	count int
+}
+
+func TestCopy_GenericUnexportedTypeArgument(t *testing.T) {
+	first := &secretElem{Value: 1, hidden: 1}
+	src := genericContainer[secretElem]{
+		Items: []secretElem{{Value: 1, hidden: 1}, {Value: 2, hidden: 2}},
+		Index: map[string]*secretElem{"first": first},
+		Last:  secretElem{Value: 3, hidden: 3},
+		count: 2,
+	}
+
+	dst, err := Copy(src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	expected := genericContainer[secretElem]{
+		Items: []secretElem{{Value: 1}, {Value: 2}},
+		Index: map[string]*secretElem{"first": {Value: 1}},
+		Last:  secretElem{Value: 3},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, dst)
+	}
+
+	dst, err = Copy(src, WithUnexportedFieldsFor(reflect.TypeFor[secretElem]().PkgPath()))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("Expected %+v, got %+v", src, dst)
+	}
+}
+
+func TestCopy_WithUnexportedFieldsFor_AnonymousStruct(t *testing.T) {
+	// Anonymous struct types have no package of their own, so the one
+	// declaring their unexported fields is used.
+	src := struct {
+		Public  int
+		private *int
+	}{Public: 1, private: new(int)}
+	*src.private = 2
+
+	dst, err := Copy(src, WithUnexportedFieldsFor(reflect.TypeFor[privateFields]().PkgPath()))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if dst.Public != 1 || dst.private == nil || *dst.private != 2 {
+		t.Fatalf("Expected unexported fields to be copied, got %+v", dst)
+	}
+	if dst.private == src.private {
+		t.Errorf("Expected unexported fields to be deep copied")
+	}
+
+	if dst, err := Copy(src, WithUnexportedFieldsFor("other/pkg")); err != nil ||
+		dst.private != nil {
+		t.Errorf("Expected unexported fields of other packages to be skipped")
+	}
+}